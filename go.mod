@@ -3,7 +3,11 @@ module github.com/andr3wV/Exchange
 go 1.21.3
 
 require (
-	github.com/labstack/echo/v4 v4.11.2 // indirect
+	github.com/labstack/echo/v4 v4.11.2
+	github.com/shopspring/decimal v1.3.1
+)
+
+require (
 	github.com/labstack/gommon v0.4.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
@@ -136,7 +136,9 @@ func (ex *Exchange) cancelOrder(c echo.Context) error {
 
 	ob := ex.orderbooks[MarketETH]
 	order := ob.Orders[int64(id)]
-	ob.CancelOrder(order)
+	if err := ob.CancelOrder(order); err != nil {
+		return err
+	}
 
 	return c.JSON(200, map[string]any{"msg": "Order cancelled!"})
 }
@@ -149,7 +151,6 @@ type MatchedOrder struct {
 
 func (ex *Exchange) handlePlaceOrder(c echo.Context) error {
 	var placeOrderData PlaceOrderRequest
-	var matches []orderbook.Match
 	if err := json.NewDecoder(c.Request().Body).Decode(&placeOrderData); err != nil {
 		return err
 	}
@@ -158,10 +159,17 @@ func (ex *Exchange) handlePlaceOrder(c echo.Context) error {
 	ob := ex.orderbooks[market]
 	order := orderbook.NewOrder(placeOrderData.Bid, placeOrderData.Size)
 
+	var (
+		matches []orderbook.Match
+		err     error
+	)
 	if placeOrderData.Type == LimitOrder {
-		matches = ob.PlaceLimitOrder(placeOrderData.Price, order)
+		matches, err = ob.PlaceLimitOrderCtx(c.Request().Context(), placeOrderData.Price, order)
 	} else {
-		matches = ob.PlaceMarketOrder(order)
+		matches, err = ob.PlaceMarketOrderCtx(c.Request().Context(), order)
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"msg": err.Error()})
 	}
 
 	matchedOrders := make([]*MatchedOrder, len(matches))
@@ -0,0 +1,57 @@
+package orderbook
+
+import "errors"
+
+var ErrInsufficientFunds = errors.New("trader can't afford the order")
+
+// Decides whether a trader can afford an order before it's placed. The book
+// knows nothing about balances, it only asks. price is the limit price, for
+// market orders it's the protection price (MaxPrice or MinPrice), or 0 when
+// there is none.
+type AccountChecker interface {
+	CanPlace(traderID string, o *Order, price float64) error
+}
+
+// A bare bones AccountChecker holding balances in memory. Buys need the
+// quote to pay size * price, sells need the base they sell. Nothing is
+// reserved or debited, that's up to the caller.
+type Balances struct {
+	Base  map[string]float64 // per trader, what they can sell
+	Quote map[string]float64 // per trader, what they can spend
+}
+
+func NewBalances() *Balances {
+	return &Balances{
+		Base:  make(map[string]float64),
+		Quote: make(map[string]float64),
+	}
+}
+
+func (b *Balances) CanPlace(traderID string, o *Order, price float64) error {
+	if !o.Bid {
+		if b.Base[traderID] < o.Size {
+			return ErrInsufficientFunds
+		}
+		return nil
+	}
+
+	quote := b.Quote[traderID]
+	if price == 0 {
+		// A market buy without protection can't be priced up front
+		if quote <= 0 {
+			return ErrInsufficientFunds
+		}
+		return nil
+	}
+	if quote < o.Size*price {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (ob *Orderbook) canPlace(o *Order, price float64) error {
+	if ob.Accounts == nil {
+		return nil
+	}
+	return ob.Accounts.CanPlace(o.TraderID, o, price)
+}
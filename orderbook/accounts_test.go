@@ -0,0 +1,46 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountChecker(t *testing.T) {
+	ob := NewOrderBook()
+	balances := NewBalances()
+	balances.Quote["alice"] = 1000
+	balances.Base["bob"] = 2
+	ob.Accounts = balances
+
+	// 11 * 100 is more than alice has
+	_, err := ob.PlaceLimitOrder(100, NewOrder(true, 11, WithTraderID("alice")))
+	assert(t, errors.Is(err, ErrInsufficientFunds), true)
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 10, WithTraderID("alice")))
+	assert(t, err, nil)
+
+	_, err = ob.PlaceLimitOrder(101, NewOrder(false, 3, WithTraderID("bob")))
+	assert(t, errors.Is(err, ErrInsufficientFunds), true)
+	_, err = ob.PlaceMarketOrder(NewOrder(false, 3, WithTraderID("bob")))
+	assert(t, errors.Is(err, ErrInsufficientFunds), true)
+	matches, err := ob.PlaceMarketOrder(NewOrder(false, 2, WithTraderID("bob")))
+	assert(t, err, nil)
+	assert(t, len(matches), 1)
+
+	// Unknown traders have nothing
+	_, err = ob.PlaceStopOrder(NewOrder(true, 1, WithTraderID("carol"), WithStopPrice(120)))
+	assert(t, errors.Is(err, ErrInsufficientFunds), true)
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, errors.Is(err, ErrInsufficientFunds), true)
+
+	assert(t, ob.BidTotalVolume(), 8.0)
+	assert(t, len(ob.PendingStops()), 0)
+}
+
+func TestBalancesMarketBuy(t *testing.T) {
+	balances := NewBalances()
+	balances.Quote["alice"] = 500
+
+	assert(t, balances.CanPlace("alice", NewOrder(true, 100), 0), nil)
+	assert(t, balances.CanPlace("bob", NewOrder(true, 1), 0), ErrInsufficientFunds)
+	assert(t, balances.CanPlace("alice", NewOrder(true, 6), 100), ErrInsufficientFunds) // protected at 100
+}
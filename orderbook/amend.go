@@ -0,0 +1,156 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrOrderNotFound  = errors.New("order not found")
+	ErrReduceTooLarge = errors.New("reduction is larger than the order's remaining size")
+)
+
+// Changes the price and/or size of a resting order. Shrinking the size at the
+// same price keeps the order's place in the queue, anything else sends it to
+// the back of the (new) limit like a fresh order would. For icebergs newSize is
+// the full remaining size, peak and reserve together. A new price that
+// crosses the book trades right away, the matches end up on the tape.
+func (ob *Orderbook) AmendOrder(id int64, newPrice, newSize float64) error {
+	o, ok := ob.Orders[id]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if err := ob.allow(o); err != nil {
+		return err
+	}
+
+	if err := ob.validateSize(newSize); err != nil {
+		return err
+	}
+	if err := validatePrice(newPrice); err != nil {
+		return err
+	}
+	if ob.TickSize > 0 && !isMultiple(newPrice, ob.TickSize) {
+		return ErrInvalidTick
+	}
+
+	limit := o.Limit
+	inPlace := newPrice == limit.Price && newSize <= o.Size && o.Hidden == 0
+	if !inPlace {
+		// checked as the order it's about to become
+		amended := *o
+		amended.Size, amended.Hidden = newSize, 0
+		if err := ob.admit(&amended, newPrice, true); err != nil {
+			return err
+		}
+	}
+	if err := ob.journal(journalRecord{Op: opAmend, ID: id, Prices: []float64{newPrice}, Size: newSize}); err != nil {
+		return err
+	}
+
+	if inPlace {
+		ob.touch(o.Bid, limit)
+		limit.addVolume(o.Bid, newSize-o.Size)
+		o.Size = newSize
+		ob.publishOrder(OrderModified, o, limit.Price)
+		ob.flushDepth()
+		return nil
+	}
+
+	ob.unrest(o)
+	o.Size = newSize
+	o.Hidden = 0 // an iceberg is split into peak and reserve again when it rests
+	o.Timestamp = ob.stamp()
+
+	ob.placeLimitOrder(newPrice, o)
+	ob.settle()
+
+	return nil
+}
+
+// Shrinks a resting order by reduceBy without moving it in the queue, e.g. to
+// pull part of a quote. An iceberg gives up its reserve first. Reducing by
+// the whole remaining size cancels the order, by more is rejected with
+// ErrReduceTooLarge.
+func (ob *Orderbook) ReduceOrder(id int64, reduceBy float64) error {
+	o, ok := ob.Orders[id]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if err := ob.allow(o); err != nil {
+		return err
+	}
+	if reduceBy <= 0 || math.IsNaN(reduceBy) || math.IsInf(reduceBy, 0) {
+		return ErrInvalidSize
+	}
+
+	remaining := o.Size + o.Hidden
+	if reduceBy > remaining {
+		return ErrReduceTooLarge
+	}
+	if reduceBy == remaining {
+		ob.cancel(o)
+		return nil
+	}
+	if err := ob.validateSize(remaining - reduceBy); err != nil {
+		return err
+	}
+	if err := ob.journal(journalRecord{Op: opReduce, ID: id, Size: reduceBy}); err != nil {
+		return err
+	}
+
+	fromHidden := math.Min(o.Hidden, reduceBy)
+	fromVisible := reduceBy - fromHidden
+	o.Hidden -= fromHidden
+
+	if fromVisible > 0 {
+		limit := o.Limit
+		ob.touch(o.Bid, limit)
+		limit.addVolume(o.Bid, -fromVisible)
+		o.Size -= fromVisible
+		ob.publishOrder(OrderModified, o, limit.Price)
+		ob.flushDepth()
+	}
+
+	return nil
+}
+
+// Cancels a resting order and places a new one with the same owner and
+// settings at newPrice and newSize, returning the new order's id. It's all or
+// nothing: the replacement is checked and journaled first, and if either fails
+// the old order stays where it was.
+func (ob *Orderbook) CancelReplace(oldID int64, newPrice, newSize float64) (int64, []Match, error) {
+	old, ok := ob.Orders[oldID]
+	if !ok {
+		return 0, nil, ErrOrderNotFound
+	}
+	replacement := NewOrder(old.Bid, newSize, WithTraderID(old.TraderID))
+	replacement.TimeInForce = old.TimeInForce
+	replacement.ExpireAt = old.ExpireAt
+	replacement.AON = old.AON
+	replacement.DisplaySize = old.DisplaySize
+
+	// Taking the old order out can free up a level for the new one
+	freesLevel := len(old.Limit.Orders) == 1 && old.Limit.Price != newPrice
+	if err := ob.checkPlacement(replacement, newPrice, true); err != nil && !(err == ErrBookFull && freesLevel) {
+		return 0, nil, err
+	}
+	if err := ob.validateLimit(newPrice, replacement); err != nil {
+		return 0, nil, err
+	}
+	rec := journalRecord{Op: opReplace, ID: oldID, Prices: []float64{newPrice}, Orders: []OrderSnapshot{snapshotOrder(replacement)}}
+	if err := ob.journal(rec); err != nil {
+		return 0, nil, err
+	}
+
+	return replacement.ID, ob.replace(old, newPrice, replacement), nil
+}
+
+func (ob *Orderbook) replace(old *Order, price float64, replacement *Order) []Match {
+	ob.cancelOrder(old)
+	old.Status = StatusCancelled
+
+	ob.metrics().IncOrdersPlaced(replacement.Bid)
+	matches := ob.placeLimitOrder(price, replacement)
+	return append(matches, ob.settle()...)
+}
@@ -0,0 +1,210 @@
+package orderbook
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAmendOrderSizeDownKeepsPriority(t *testing.T) {
+	ob := NewOrderBook()
+	first := NewOrder(true, 10)
+	second := NewOrder(true, 5)
+	ob.PlaceLimitOrder(10_000, first)
+	ob.PlaceLimitOrder(10_000, second)
+
+	err := ob.AmendOrder(first.ID, 10_000, 4)
+
+	assert(t, err, nil)
+	assert(t, first.Size, 4.0)
+	assert(t, ob.BidLimits[10_000].Orders[0], first)
+	assert(t, ob.BidLimits[10_000].TotalVolume, 9.0)
+	assert(t, ob.BidTotalVolume(), 9.0)
+}
+
+func TestAmendOrderSizeUpLosesPriority(t *testing.T) {
+	ob := NewOrderBook()
+	first := NewOrder(true, 10)
+	second := NewOrder(true, 5)
+	ob.PlaceLimitOrder(10_000, first)
+	ob.PlaceLimitOrder(10_000, second)
+
+	err := ob.AmendOrder(first.ID, 10_000, 12)
+
+	assert(t, err, nil)
+	assert(t, ob.BidLimits[10_000].Orders, Orders{second, first})
+	assert(t, ob.BidLimits[10_000].TotalVolume, 17.0)
+}
+
+func TestAmendOrderPriceChangeLosesPriority(t *testing.T) {
+	ob := NewOrderBook()
+	moving := NewOrder(false, 3)
+	waiting := NewOrder(false, 2)
+	ob.PlaceLimitOrder(10_000, moving)
+	ob.PlaceLimitOrder(11_000, waiting)
+
+	err := ob.AmendOrder(moving.ID, 11_000, 3)
+
+	assert(t, err, nil)
+	assert(t, moving.Limit, ob.AskLimits[11_000])
+	assert(t, ob.AskLimits[11_000].Orders, Orders{waiting, moving})
+	assert(t, ob.AskLimits[11_000].TotalVolume, 5.0)
+	assert(t, len(ob.Asks()), 1) // the old 10,000 level is gone
+	assert(t, ob.AskTotalVolume(), 5.0)
+}
+
+func TestAmendOrderErrors(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TickSize = 1
+	o := NewOrder(true, 3)
+	ob.PlaceLimitOrder(10_000, o)
+
+	assert(t, ob.AmendOrder(12345, 10_000, 1), ErrOrderNotFound)
+	assert(t, ob.AmendOrder(o.ID, 10_000, 0), ErrInvalidSize)
+	assert(t, ob.AmendOrder(o.ID, 10_000.5, 3), ErrInvalidTick)
+
+	// A rejected amend leaves the order where it was
+	assert(t, o.Limit, ob.BidLimits[10_000])
+	assert(t, ob.BidTotalVolume(), 3.0)
+}
+
+func TestCancelReplace(t *testing.T) {
+	ob := NewOrderBook()
+	old := NewOrder(true, 3, WithTraderID("alice"))
+	ob.PlaceLimitOrder(99, old)
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+
+	newID, matches, err := ob.CancelReplace(old.ID, 101, 4)
+	assert(t, err, nil)
+	assert(t, newID != old.ID, true)
+	assert(t, len(matches), 1)
+	assert(t, old.Status, StatusCancelled)
+	assert(t, old.Limit == nil, true)
+
+	replacement := ob.Orders[newID]
+	assert(t, replacement.TraderID, "alice")
+	assert(t, replacement.Size, 3.0)
+	assert(t, replacement.Limit.Price, 101.0)
+	assert(t, ob.BidTotalVolume(), 3.0)
+	assert(t, len(ob.BidLimits), 1)
+
+	_, _, err = ob.CancelReplace(old.ID, 100, 1)
+	assert(t, errors.Is(err, ErrOrderNotFound), true)
+}
+
+func TestCancelReplaceRollsBack(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TickSize = 0.5
+	old := NewOrder(true, 3)
+	ob.PlaceLimitOrder(99, old)
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	before := ob.Snapshot()
+
+	_, _, err := ob.CancelReplace(old.ID, 99.3, 3)
+	assert(t, errors.Is(err, ErrInvalidTick), true)
+	_, _, err = ob.CancelReplace(old.ID, 99, 0)
+	assert(t, errors.Is(err, ErrInvalidSize), true)
+
+	assert(t, ob.Snapshot(), before)
+	assert(t, ob.BestBid().Orders[0], old)
+	assert(t, old.Status, StatusNew)
+}
+
+func TestCancelReplaceFreesLevel(t *testing.T) {
+	ob := NewOrderBook()
+	ob.MaxLevelsPerSide = 1
+	old := NewOrder(false, 1)
+	ob.PlaceLimitOrder(101, old)
+
+	// The only order at the only level, moving it is fine
+	newID, _, err := ob.CancelReplace(old.ID, 102, 1)
+	assert(t, err, nil)
+	assert(t, ob.Asks()[0].Price, 102.0)
+
+	ob.PlaceLimitOrder(102, NewOrder(false, 1))
+	_, _, err = ob.CancelReplace(newID, 103, 1)
+	assert(t, errors.Is(err, ErrBookFull), true)
+	assert(t, ob.AskTotalVolume(), 2.0)
+}
+
+// Takes the first ok writes, fails the rest
+type failAfterWriter struct{ ok int }
+
+func (w *failAfterWriter) Write(p []byte) (int, error) {
+	if w.ok == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.ok--
+	return len(p), nil
+}
+
+func TestCancelReplaceJournalFailureKeepsOldOrder(t *testing.T) {
+	ob := NewOrderBook()
+	old := NewOrder(true, 3)
+	ob.PlaceLimitOrder(99, old)
+	assert(t, ob.SetJournal(&failAfterWriter{ok: 1}), nil)
+
+	_, _, err := ob.CancelReplace(old.ID, 100, 2)
+	assert(t, err != nil, true)
+	assert(t, ob.BestBid().Orders[0], old)
+	assert(t, old.Status, StatusNew)
+	assert(t, len(ob.Orders), 1)
+}
+
+func TestCancelReplaceReplay(t *testing.T) {
+	ob := NewOrderBook()
+	var journal bytes.Buffer
+	ob.SetJournal(&journal)
+
+	old := NewOrder(true, 3, WithTraderID("alice"))
+	ob.PlaceLimitOrder(99, old)
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	newID, _, err := ob.CancelReplace(old.ID, 100, 3)
+	assert(t, err, nil)
+
+	replayed, err := Replay(&journal)
+	assert(t, err, nil)
+	assertSameBook(t, ob, replayed)
+	assert(t, replayed.Orders[newID].Limit.Price, 100.0)
+}
+
+func TestReduceOrderKeepsPriority(t *testing.T) {
+	ob := NewOrderBook()
+	first := NewOrder(false, 5)
+	second := NewOrder(false, 5)
+	ob.PlaceLimitOrder(100, first)
+	ob.PlaceLimitOrder(100, second)
+
+	assert(t, ob.ReduceOrder(first.ID, 3), nil)
+	assert(t, first.Size, 2.0)
+	assert(t, ob.AskLimits[100].TotalVolume, 7.0)
+	assert(t, ob.AskTotalVolume(), 7.0)
+
+	matches, _ := ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, matches[0].Ask, first) // still first in line
+
+	// an iceberg gives up its reserve first
+	iceberg := NewOrder(true, 10, WithDisplaySize(2))
+	ob.PlaceLimitOrder(90, iceberg)
+	assert(t, ob.ReduceOrder(iceberg.ID, 9), nil)
+	assert(t, iceberg.Size, 1.0)
+	assert(t, iceberg.Hidden, 0.0)
+	assert(t, ob.BidTotalVolume(), 1.0)
+}
+
+func TestReduceOrderTooMuch(t *testing.T) {
+	ob := NewOrderBook()
+	o := NewOrder(false, 5)
+	ob.PlaceLimitOrder(100, o)
+
+	assert(t, ob.ReduceOrder(o.ID, 6), ErrReduceTooLarge)
+	assert(t, o.Size, 5.0)
+	assert(t, ob.ReduceOrder(o.ID, 0), ErrInvalidSize)
+	assert(t, ob.ReduceOrder(12345, 1), ErrOrderNotFound)
+
+	// all of it is a cancel
+	assert(t, ob.ReduceOrder(o.ID, 5), nil)
+	assert(t, o.Status, StatusCancelled)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, ob.AskTotalVolume(), 0.0)
+}
@@ -0,0 +1,17 @@
+package orderbook
+
+// Makes the order all or none. It rests like any limit order, but only ever
+// trades when the other side can take its whole remaining size at once.
+// Arriving, it only matches if the book can fill all of it, otherwise it
+// just rests.
+func WithAllOrNone() OrderOption {
+	return func(o *Order) {
+		o.AON = true
+	}
+}
+
+// Whether an incoming order with remaining size still to fill can trade with
+// resting, an all-or-none resting order needs all of it taken
+func canTakeAll(remaining float64, resting *Order) bool {
+	return !resting.AON || remaining >= resting.Size+resting.Hidden
+}
@@ -0,0 +1,96 @@
+package orderbook
+
+import "testing"
+
+func TestAONRestingOrderIsSkippedThenFilled(t *testing.T) {
+	ob := NewOrderBook()
+	aon := NewOrder(false, 5, WithAllOrNone())
+	behind := NewOrder(false, 2)
+	ob.PlaceLimitOrder(100, aon)
+	ob.PlaceLimitOrder(100, behind)
+
+	// Too small for the AON order, the order behind it trades instead
+	matches, _ := ob.PlaceLimitOrder(100, NewOrder(true, 3))
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Ask, behind)
+	assert(t, aon.Size, 5.0)
+	assert(t, ob.BidTotalVolume(), 1.0) // the rest of the buy rests
+	assert(t, ob.IsLocked(), true)
+
+	// Big enough to take all of it
+	matches, _ = ob.PlaceLimitOrder(100, NewOrder(true, 6))
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Ask, aon)
+	assert(t, matches[0].SizeFilled, 5.0)
+	assert(t, aon.Status, StatusFilled)
+}
+
+func TestAONIncomingOrder(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+
+	// Only 2 of 3 available, so it doesn't trade at all and rests
+	aon := NewOrder(true, 3, WithAllOrNone())
+	matches, _ := ob.PlaceLimitOrder(100, aon)
+	assert(t, len(matches), 0)
+	assert(t, aon.Size, 3.0)
+	assert(t, ob.AskTotalVolume(), 2.0)
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	assert(t, aon.Size, 3.0) // 1 isn't enough
+	assert(t, ob.AskTotalVolume(), 3.0)
+
+	matches, _ = ob.PlaceLimitOrder(100, NewOrder(false, 3))
+	assert(t, len(matches), 1)
+	assert(t, aon.IsFilled(), true)
+}
+
+func TestAONProRata(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Matching = ProRata
+	aon := NewOrder(false, 4, WithAllOrNone())
+	a, b := NewOrder(false, 2), NewOrder(false, 2)
+	ob.PlaceLimitOrder(100, aon)
+	ob.PlaceLimitOrder(100, a)
+	ob.PlaceLimitOrder(100, b)
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 2))
+	assert(t, aon.Size, 4.0)
+	assert(t, a.Size, 1.0)
+	assert(t, b.Size, 1.0)
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 6))
+	assert(t, aon.Status, StatusFilled)
+	assert(t, len(ob.Asks()), 0)
+}
+
+func TestAONStopsAtOwnOrder(t *testing.T) {
+	ob := NewOrderBook() // STPCancelNewest by default
+	ob.PlaceLimitOrder(100, NewOrder(false, 1, WithTraderID("bob")))
+	ob.PlaceLimitOrder(100, NewOrder(false, 1, WithTraderID("alice")))
+	ob.PlaceLimitOrder(100, NewOrder(false, 5, WithTraderID("bob")))
+
+	// Only 1 trades before her own ask would cancel it, so it doesn't trade and rests
+	aon := NewOrder(true, 2, WithTraderID("alice"), WithAllOrNone())
+	matches, err := ob.PlaceLimitOrder(100, aon)
+	assert(t, err, nil)
+	assert(t, len(matches), 0)
+	assert(t, aon.Size, 2.0)
+	assert(t, aon.Status, StatusNew)
+	assert(t, ob.BidTotalVolume(), 2.0)
+	assert(t, ob.AskTotalVolume(), 7.0)
+}
+
+func TestFOKCountsAONAgainstWhatIsLeft(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+	ob.PlaceLimitOrder(101, NewOrder(false, 8, WithAllOrNone()))
+
+	// 5 fill at 100, the 5 left can't take the AON order of 8
+	fok := NewOrder(true, 10, WithTimeInForce(FOK))
+	matches, err := ob.PlaceLimitOrder(101, fok)
+	assert(t, err, ErrFillOrKill)
+	assert(t, len(matches), 0)
+	assert(t, ob.AskTotalVolume(), 13.0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+}
@@ -0,0 +1,142 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrInAuction = errors.New("order type isn't accepted during an auction")
+	ErrNoCross   = errors.New("bids and asks don't cross, there is no clearing price")
+)
+
+// Starts a call auction, e.g. for the open or the close. Limit orders collect
+// on the book without matching, so it can cross, until Uncross clears them
+// at one price. Market, FOK and IOC orders are rejected with ErrInAuction.
+func (ob *Orderbook) StartAuction() {
+	ob.journal(journalRecord{Op: opAuction})
+	ob.auction = true
+}
+
+func (ob *Orderbook) InAuction() bool {
+	return ob.auction
+}
+
+// Ends the auction and trades every crossing order at the single price that
+// executes the most volume. Ties go to the price that leaves the smallest
+// imbalance, then to the one closest to the last trade (or the mid without
+// one). Orders trade in price-time priority; STP and AON don't apply to the
+// uncross and the ask of each match is charged the maker fee. Returns
+// ErrNoCross, with the auction over all the same, when nothing crosses.
+func (ob *Orderbook) Uncross() (clearingPrice float64, matches []Match, err error) {
+	if err := ob.journal(journalRecord{Op: opUncross}); err != nil {
+		return 0, nil, err
+	}
+	ob.auction = false
+
+	price, volume := ob.clearingPrice()
+	if volume <= 0 {
+		return 0, nil, ErrNoCross
+	}
+	ob.debug("auction uncrossed", "price", price, "volume", volume)
+
+	matches = ob.executeAt(price, volume)
+	ob.flushDepth()
+	matches = append(matches, ob.settle()...)
+
+	return price, matches, nil
+}
+
+func (ob *Orderbook) clearingPrice() (price, volume float64) {
+	ref, ok := ob.LastPrice()
+	if !ok {
+		ref, _ = ob.MidPrice()
+	}
+
+	var imbalance float64
+	for _, l := range append(ob.Asks(), ob.Bids()...) {
+		demand := ob.volumeThrough(true, l.Price)
+		supply := ob.volumeThrough(false, l.Price)
+		v := math.Min(demand, supply)
+		imb := math.Abs(demand - supply)
+
+		switch {
+		case v <= 0 || v < volume:
+			continue
+		case v == volume && imb > imbalance:
+			continue
+		case v == volume && imb == imbalance && math.Abs(l.Price-ref) >= math.Abs(price-ref):
+			continue
+		}
+		price, volume, imbalance = l.Price, v, imb
+	}
+
+	return price, volume
+}
+
+// Size on one side willing to trade at price: bids at or above it, asks at or
+// below it, iceberg reserves included
+func (ob *Orderbook) volumeThrough(bid bool, price float64) float64 {
+	volume := 0.0
+	ob.levels(bid).Iterate(func(l *Limit) bool {
+		if better(bid, price, l.Price) {
+			return false
+		}
+		for _, o := range l.Orders {
+			volume += o.Size + o.Hidden
+		}
+		return true
+	})
+	return volume
+}
+
+// Pairs off the best bid and ask, oldest orders first, until volume has traded at price
+func (ob *Orderbook) executeAt(price, volume float64) []Match {
+	matches := []Match{}
+
+	for volume > 0 {
+		bidLimit, askLimit := ob.best(true), ob.best(false)
+		if bidLimit == nil || askLimit == nil || bidLimit.Price < price || askLimit.Price > price {
+			break
+		}
+		bid, ask := bidLimit.Orders[0], askLimit.Orders[0]
+		size := math.Min(volume, math.Min(bid.Size, ask.Size))
+
+		ob.touch(true, bidLimit)
+		ob.touch(false, askLimit)
+
+		match, ok := askLimit.fillAt(ask, bid, size, price)
+		if !ok {
+			break
+		}
+		askLimit.addVolume(false, -match.SizeFilled)
+		bidLimit.addVolume(true, -match.SizeFilled)
+		if !bid.IsFilled() {
+			ob.publishOrder(OrderModified, bid, bidLimit.Price)
+		}
+		matches = append(matches, match)
+		volume -= match.SizeFilled
+
+		ob.takeFilled(bidLimit, bid)
+		ob.takeFilled(askLimit, ask)
+	}
+
+	return matches
+}
+
+// Takes an order that just filled off its level, or shows its next iceberg peak
+func (ob *Orderbook) takeFilled(l *Limit, o *Order) {
+	if !o.IsFilled() {
+		return
+	}
+	if o.Hidden > 0 {
+		l.refreshPeak(o)
+		return
+	}
+
+	l.DeleteOrder(o)
+	ob.untrackOrder(o)
+	if len(l.Orders) == 0 {
+		ob.clearLimit(o.Bid, l)
+	}
+}
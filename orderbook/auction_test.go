@@ -0,0 +1,113 @@
+package orderbook
+
+import (
+	"bytes"
+	"testing"
+)
+
+func auctionBook() *Orderbook {
+	ob := NewOrderBook()
+	ob.StartAuction()
+
+	ob.PlaceLimitOrder(102, NewOrder(true, 5))
+	ob.PlaceLimitOrder(101, NewOrder(true, 5))
+	ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	ob.PlaceLimitOrder(99, NewOrder(false, 4))
+	ob.PlaceLimitOrder(100, NewOrder(false, 6))
+	ob.PlaceLimitOrder(101, NewOrder(false, 10))
+
+	return ob
+}
+
+func TestAuctionCollectsOrders(t *testing.T) {
+	ob := auctionBook()
+
+	assert(t, ob.InAuction(), true)
+	assert(t, len(ob.Trades()), 0)
+	assert(t, ob.BestBid().Price, 102.0)
+	assert(t, ob.BestAsk().Price, 99.0)
+	assert(t, ob.Verify(), nil)
+
+	_, err := ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, err, ErrInAuction)
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1, WithTimeInForce(IOC)))
+	assert(t, err, ErrInAuction)
+}
+
+func TestUncross(t *testing.T) {
+	ob := auctionBook()
+
+	// 100 and 101 both clear 10, 100 leaves 5 over instead of 10
+	price, matches, err := ob.Uncross()
+	assert(t, err, nil)
+	assert(t, price, 100.0)
+
+	volume := 0.0
+	for _, m := range matches {
+		assert(t, m.Price, 100.0)
+		volume += m.SizeFilled
+	}
+	assert(t, volume, 10.0)
+	assert(t, len(matches), 3)
+
+	assert(t, ob.InAuction(), false)
+	assert(t, ob.BestBid().Price, 100.0)
+	assert(t, ob.BidTotalVolume(), 5.0)
+	assert(t, ob.BestAsk().Price, 101.0)
+	assert(t, ob.AskTotalVolume(), 10.0)
+	assert(t, ob.Verify(), nil)
+
+	// back to continuous trading
+	matches, _ = ob.PlaceLimitOrder(101, NewOrder(true, 1))
+	assert(t, len(matches), 1)
+}
+
+func TestUncrossNothingCrosses(t *testing.T) {
+	ob := NewOrderBook()
+	ob.StartAuction()
+	ob.PlaceLimitOrder(99, NewOrder(true, 5))
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+	_, matches, err := ob.Uncross()
+	assert(t, err, ErrNoCross)
+	assert(t, len(matches), 0)
+	assert(t, ob.InAuction(), false)
+}
+
+func TestUncrossReplay(t *testing.T) {
+	var journal bytes.Buffer
+	ob := NewOrderBook()
+	ob.SetJournal(&journal)
+
+	ob.StartAuction()
+	ob.PlaceLimitOrder(101, NewOrder(true, 3))
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+	ob.Uncross()
+
+	replayed, err := Replay(&journal)
+	assert(t, err, nil)
+	assert(t, replayed.InAuction(), false)
+	assert(t, len(replayed.Trades()), 1)
+	assert(t, replayed.Depth(), ob.Depth())
+}
+
+func TestStopMarketWaitsForUncross(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+	ob.PlaceMarketOrder(NewOrder(true, 1)) // last price 100
+	ob.StartAuction()
+
+	// Already triggered, but nothing trades until the uncross
+	stop := NewOrder(true, 2, WithStopPrice(99))
+	matches, err := ob.PlaceStopOrder(stop)
+	assert(t, err, nil)
+	assert(t, len(matches), 0)
+	assert(t, ob.PendingStops(), []*Order{stop})
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	_, matches, err = ob.Uncross()
+	assert(t, err, nil)
+	assert(t, len(matches), 2) // the uncross, then the stop
+	assert(t, matches[1].Bid, stop)
+	assert(t, len(ob.PendingStops()), 0)
+}
@@ -0,0 +1,83 @@
+package orderbook
+
+import (
+	"sort"
+	"time"
+)
+
+// One order of a historical stream, sent in at At
+type TimedOrder struct {
+	At time.Time
+	OrderRequest
+}
+
+// Plays a stream of orders into a fresh book on a simulated clock and
+// returns the final book and every match, stop cascades included. The clock
+// jumps to each order's time, GTD orders that expired by then are cancelled
+// first, and the order is stamped with that time so queue priority follows
+// the stream. Orders with equal times keep their order in the stream.
+// An order with an ActivateAt is scheduled, the clock stops at every
+// activation time on its way to the next order so it goes in when it's due,
+// stamped with that time. Anything due after the last order stays pending.
+// Rejected orders are skipped, and so is an unprotected market order the book
+// can't fill, placing it comes back with ErrNotEnoughVolume.
+func Backtest(orders []TimedOrder) (*Orderbook, []Match) {
+	stream := make([]TimedOrder, len(orders))
+	copy(stream, orders)
+	sort.SliceStable(stream, func(i, j int) bool { return stream[i].At.Before(stream[j].At) })
+
+	ob := NewOrderBook()
+	var now time.Time
+	ob.SetClock(func() time.Time { return now })
+
+	advance := func(to time.Time) {
+		now = to
+		ob.ExpireOrders(now.UnixNano())
+		ob.ActivateDue(now.UnixNano())
+	}
+
+	for _, to := range stream {
+		for _, at := range activationTimes(ob.scheduled, to.At.UnixNano()) {
+			advance(time.Unix(0, at))
+		}
+		advance(to.At)
+
+		to.Order.Timestamp = now.UnixNano()
+		if to.Order.ActivateAt > 0 {
+			if to.Order.ActivateAt > to.Order.Timestamp {
+				to.Order.Timestamp = to.Order.ActivateAt
+			}
+			if ob.PlaceScheduledOrder(to.Order) == nil {
+				ob.ActivateDue(now.UnixNano()) // already due
+			}
+			continue
+		}
+
+		if to.Market {
+			ob.PlaceMarketOrder(to.Order)
+		} else {
+			ob.PlaceLimitOrder(to.Price, to.Order)
+		}
+	}
+
+	return ob, ob.Trades()
+}
+
+// The distinct activation times of the scheduled orders before until, earliest first
+func activationTimes(scheduled []*Order, until int64) []int64 {
+	var times []int64
+	for _, o := range scheduled {
+		if o.ActivateAt < until {
+			times = append(times, o.ActivateAt)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	var distinct []int64
+	for _, at := range times {
+		if len(distinct) == 0 || at != distinct[len(distinct)-1] {
+			distinct = append(distinct, at)
+		}
+	}
+	return distinct
+}
@@ -0,0 +1,91 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBacktest(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	first := NewOrderWithID(1, false, 2)
+	second := NewOrderWithID(2, false, 2)
+	gtd := NewOrderWithID(3, false, 5, WithGoodTillDate(at(15).UnixNano()))
+	buy := NewOrderWithID(4, true, 3)
+	late := NewOrderWithID(5, true, 4)
+
+	book, matches := Backtest([]TimedOrder{
+		{At: at(0), OrderRequest: OrderRequest{Order: second, Price: 100}},
+		{At: at(5), OrderRequest: OrderRequest{Order: gtd, Price: 101}},
+		{At: at(10), OrderRequest: OrderRequest{Order: buy, Market: true}},
+		{At: at(20), OrderRequest: OrderRequest{Order: late, Price: 101}},
+		{At: at(0), OrderRequest: OrderRequest{Order: NewOrderWithID(6, true, 0), Price: 99}}, // rejected
+		{At: at(-5), OrderRequest: OrderRequest{Order: first, Price: 100}},                    // out of order, goes first
+	})
+
+	// buy takes first then some of second, the GTD is gone by the time
+	// late comes in so it only gets the rest of second
+	assert(t, len(matches), 3)
+	assert(t, matches[0].Ask, first)
+	assert(t, matches[0].SizeFilled, 2.0)
+	assert(t, matches[0].Timestamp, at(10).UnixNano())
+	assert(t, matches[1].Ask, second)
+	assert(t, matches[1].SizeFilled, 1.0)
+	assert(t, matches[2].Bid, late)
+	assert(t, matches[2].Ask, second)
+	assert(t, matches[2].Timestamp, at(20).UnixNano())
+
+	assert(t, gtd.Status, StatusCancelled)
+	assert(t, book.AskTotalVolume(), 0.0)
+	assert(t, book.BidTotalVolume(), 3.0)
+	assert(t, book.BestBid().Orders[0], late)
+	assert(t, late.Timestamp, at(20).UnixNano())
+}
+
+func TestBacktestIcebergRefreshUsesSimulatedTime(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	iceberg := NewOrderWithID(1, false, 2, WithDisplaySize(1))
+	behind := NewOrderWithID(3, false, 1)
+	_, matches := Backtest([]TimedOrder{
+		{At: at(0), OrderRequest: OrderRequest{Order: iceberg, Price: 100}},
+		{At: at(1), OrderRequest: OrderRequest{Order: NewOrderWithID(2, true, 1), Market: true}},
+		{At: at(2), OrderRequest: OrderRequest{Order: behind, Price: 100}},
+		{At: at(3), OrderRequest: OrderRequest{Order: NewOrderWithID(4, true, 1), Market: true}},
+	})
+
+	// The new peak is stamped with the time it was shown, ahead of the order placed after it
+	assert(t, iceberg.Timestamp, at(1).UnixNano())
+	assert(t, len(matches), 2)
+	assert(t, matches[1].Ask, iceberg)
+	assert(t, matches[1].Timestamp, at(3).UnixNano())
+}
+
+func TestBacktestActivatesScheduledOrders(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	gtd := NewOrderWithID(1, false, 2, WithGoodTillDate(at(7).UnixNano()))
+	open := scheduledLimit(true, 1, at(5).UnixNano(), 100)
+	pastDue := scheduledLimit(true, 1, at(-1).UnixNano(), 100)
+	tooLate := scheduledLimit(true, 1, at(30).UnixNano(), 101)
+	book, matches := Backtest([]TimedOrder{
+		{At: at(0), OrderRequest: OrderRequest{Order: gtd, Price: 100}},
+		{At: at(0), OrderRequest: OrderRequest{Order: open}},
+		{At: at(2), OrderRequest: OrderRequest{Order: pastDue}},
+		{At: at(10), OrderRequest: OrderRequest{Order: tooLate}},
+	})
+
+	// pastDue goes in straight away, open at its time, before the GTD is gone
+	assert(t, len(matches), 2)
+	assert(t, matches[0].Bid, pastDue)
+	assert(t, matches[0].Timestamp, at(2).UnixNano())
+	assert(t, matches[1].Bid, open)
+	assert(t, matches[1].Timestamp, at(5).UnixNano())
+	assert(t, gtd.Status, StatusFilled)
+
+	// nothing moves the clock to 30 after the last order
+	assert(t, book.PendingScheduled(), []*Order{tooLate})
+}
@@ -0,0 +1,53 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrOutsideBand = errors.New("limit price is outside the price band")
+	ErrHalted      = errors.New("trading is halted")
+)
+
+// Only accepts limit orders priced within pct percent of ref, e.g. ref 100
+// and pct 10 allows 90 to 110. Also the reference CircuitBreakerPct is
+// measured from. Zero for either turns the band off.
+func (ob *Orderbook) SetPriceBand(ref, pct float64) {
+	ob.bandRef = ref
+	ob.bandPct = pct
+}
+
+// Whether the circuit breaker tripped. New orders are rejected with
+// ErrHalted and stops stay parked until Resume.
+func (ob *Orderbook) Halted() bool {
+	return ob.halted
+}
+
+// Lifts a halt. Usually the band is moved with SetPriceBand first.
+func (ob *Orderbook) Resume() {
+	ob.halted = false
+}
+
+func (ob *Orderbook) checkBand(price float64) error {
+	if ob.bandRef <= 0 || ob.bandPct <= 0 {
+		return nil
+	}
+	if math.Abs(price-ob.bandRef) > ob.bandRef*ob.bandPct/100 {
+		return ErrOutsideBand
+	}
+	return nil
+}
+
+// Trips the circuit breaker when a trade prints further from the band's
+// reference than CircuitBreakerPct allows. The order that made the trade
+// finishes matching, everything after it waits for Resume.
+func (ob *Orderbook) checkCircuitBreaker(price float64) {
+	if ob.halted || ob.bandRef <= 0 || ob.CircuitBreakerPct <= 0 {
+		return
+	}
+	if math.Abs(price-ob.bandRef) > ob.bandRef*ob.CircuitBreakerPct/100 {
+		ob.debug("trading halted", "price", price, "reference", ob.bandRef)
+		ob.halted = true
+	}
+}
@@ -0,0 +1,157 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPriceBand(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetPriceBand(100, 10)
+
+	_, err := ob.PlaceLimitOrder(110, NewOrder(false, 1))
+	assert(t, err, nil)
+	_, err = ob.PlaceLimitOrder(90, NewOrder(true, 1))
+	assert(t, err, nil)
+
+	_, err = ob.PlaceLimitOrder(110.5, NewOrder(false, 1))
+	assert(t, errors.Is(err, ErrOutsideBand), true)
+	_, err = ob.PlaceLimitOrder(89, NewOrder(true, 1))
+	assert(t, errors.Is(err, ErrOutsideBand), true)
+	assert(t, ob.NumOrders(), 2)
+
+	// Market orders have no price to check
+	_, err = ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, err, nil)
+
+	ob.SetPriceBand(0, 0)
+	_, err = ob.PlaceLimitOrder(1000, NewOrder(false, 1))
+	assert(t, err, nil)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetPriceBand(100, 20)
+	ob.CircuitBreakerPct = 5
+
+	ob.PlaceLimitOrder(104, NewOrder(false, 1))
+	ob.PlaceLimitOrder(106, NewOrder(false, 1))
+	ob.PlaceLimitOrder(107, NewOrder(false, 1))
+	stop := NewOrder(true, 1, WithStopPrice(106))
+	ob.PlaceStopOrder(stop)
+
+	ob.PlaceMarketOrder(NewOrder(true, 1)) // 104, inside 5%
+	assert(t, ob.Halted(), false)
+
+	// Trips at 106, the order still finishes but the stop stays parked
+	matches, _ := ob.PlaceLimitOrder(107, NewOrder(true, 2))
+	assert(t, len(matches), 2)
+	assert(t, ob.Halted(), true)
+	assert(t, stop.Stop, true)
+	assert(t, stop.Limit == nil, true)
+
+	_, err := ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, errors.Is(err, ErrHalted), true)
+	_, err = ob.PlaceMarketOrder(NewOrder(false, 1))
+	assert(t, errors.Is(err, ErrHalted), true)
+
+	// Survives a snapshot round trip
+	assert(t, Restore(ob.Snapshot()).Halted(), true)
+
+	ob.SetPriceBand(107, 20)
+	ob.Resume()
+	assert(t, ob.Halted(), false)
+	_, err = ob.PlaceLimitOrder(108, NewOrder(false, 1))
+	assert(t, err, nil)
+}
+
+// A halted book with a bid at 99 and an ask at 101 still on it
+func haltedBook() (*Orderbook, *Order, *Order) {
+	ob := NewOrderBook()
+	ob.SetPriceBand(110, 20)
+	ob.CircuitBreakerPct = 5
+
+	bid, ask := NewOrder(true, 1), NewOrder(false, 2)
+	ob.PlaceLimitOrder(99, bid)
+	ob.PlaceLimitOrder(101, ask)
+	ob.PlaceMarketOrder(NewOrder(true, 1)) // 101 is more than 5% off 110
+	ob.SetPriceBand(100, 10)
+	return ob, bid, ask
+}
+
+func TestHaltedAmendOrder(t *testing.T) {
+	ob, bid, _ := haltedBook()
+	assert(t, ob.Halted(), true)
+
+	assert(t, errors.Is(ob.AmendOrder(bid.ID, 101, 1), ErrHalted), true)
+	assert(t, len(ob.Trades()), 1)
+	assert(t, bid.Limit.Price, 99.0)
+
+	// Shrinking in place adds nothing, that's still fine
+	assert(t, ob.AmendOrder(bid.ID, 99, 0.5), nil)
+}
+
+func TestHaltedCancelReplace(t *testing.T) {
+	ob, bid, _ := haltedBook()
+
+	_, _, err := ob.CancelReplace(bid.ID, 101, 1)
+	assert(t, errors.Is(err, ErrHalted), true)
+	assert(t, len(ob.Trades()), 1)
+	assert(t, ob.Orders[bid.ID], bid)
+}
+
+func TestHaltedPlaceOCO(t *testing.T) {
+	ob, _, _ := haltedBook()
+
+	_, err := ob.PlaceOCO(NewOrder(true, 1), NewOrder(true, 1, WithStopPrice(110)), 101, 0)
+	assert(t, errors.Is(err, ErrHalted), true)
+	assert(t, len(ob.Trades()), 1)
+	assert(t, len(ob.PendingStops()), 0)
+}
+
+func TestHaltedStopAndScheduledOrders(t *testing.T) {
+	ob, _, _ := haltedBook()
+
+	_, err := ob.PlaceStopOrder(NewOrder(true, 1, WithStopPrice(110)))
+	assert(t, errors.Is(err, ErrHalted), true)
+	assert(t, errors.Is(ob.PlaceScheduledOrder(NewOrder(true, 1, WithActivateAt(1))), ErrHalted), true)
+}
+
+func TestBandAppliesToAmendAndTriggeredStops(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetPriceBand(100, 10)
+
+	bid := NewOrder(true, 1)
+	ob.PlaceLimitOrder(95, bid)
+	assert(t, errors.Is(ob.AmendOrder(bid.ID, 80, 1), ErrOutsideBand), true)
+	assert(t, bid.Limit.Price, 95.0)
+
+	// The stop-limit's price is fine to park, by the time it triggers it's
+	// outside the band and the stop is cancelled instead of resting at 200
+	stop := NewOrder(true, 1, WithStopLimit(101, 200))
+	_, err := ob.PlaceStopOrder(stop)
+	assert(t, err, nil)
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceMarketOrder(NewOrder(true, 1))
+
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, stop.Status, StatusCancelled)
+	assert(t, stop.Limit == nil, true)
+	assert(t, ob.BestBid().Price, 95.0)
+}
+
+func TestBandHoldsPeggedOrders(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(85, NewOrder(true, 1))
+	ob.SetPriceBand(100, 10)
+	best := NewOrder(true, 1)
+	ob.PlaceLimitOrder(105, best)
+
+	pegged := NewOrder(true, 1, WithPeg(PegBid, 0))
+	ob.PlacePeggedOrder(pegged)
+
+	// The bid it follows drops to 85, outside the band, so it stays at 105
+	ob.CancelOrder(best)
+	ob.Reprice()
+	assert(t, pegged.Limit.Price, 105.0)
+}
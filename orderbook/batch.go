@@ -0,0 +1,33 @@
+package orderbook
+
+// One order of a batch, a limit order at Price unless Market is set
+type OrderRequest struct {
+	Order  *Order
+	Price  float64 // ignored for market orders
+	Market bool
+}
+
+// What placing one order of a batch did, Err is nil when it was placed
+type OrderResult struct {
+	Matches []Match
+	Err     error
+}
+
+// Places the orders one after the other under a single acquisition of the
+// book's lock, so don't call it while holding Lock. An order that fails
+// doesn't stop the rest. Results line up with reqs by index.
+func (ob *Orderbook) PlaceBatch(reqs []OrderRequest) []OrderResult {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	results := make([]OrderResult, len(reqs))
+	for i, req := range reqs {
+		if req.Market {
+			results[i].Matches, results[i].Err = ob.PlaceMarketOrder(req.Order)
+		} else {
+			results[i].Matches, results[i].Err = ob.PlaceLimitOrder(req.Price, req.Order)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,39 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPlaceBatch(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TickSize = 0.5
+
+	ask := NewOrder(false, 2)
+	bid := NewOrder(true, 1)
+	results := ob.PlaceBatch([]OrderRequest{
+		{Order: ask, Price: 101},
+		{Order: NewOrder(false, 1), Price: 101.3}, // off tick
+		{Order: bid, Price: 99},
+		{Order: NewOrder(true, 1), Market: true},
+		{Order: NewOrder(false, 0), Price: 100}, // no size
+		{Order: NewOrder(false, 1), Price: 99},
+	})
+
+	assert(t, len(results), 6)
+	assert(t, results[0], OrderResult{Matches: []Match{}})
+	assert(t, errors.Is(results[1].Err, ErrInvalidTick), true)
+	assert(t, results[2].Err, nil)
+	assert(t, len(results[3].Matches), 1)
+	assert(t, results[3].Matches[0].Ask, ask)
+	assert(t, errors.Is(results[4].Err, ErrInvalidSize), true)
+	assert(t, len(results[5].Matches), 1)
+	assert(t, results[5].Matches[0].Bid, bid)
+
+	assert(t, ob.AskTotalVolume(), 1.0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+
+	// The lock is free again
+	ob.Lock()
+	ob.Unlock()
+}
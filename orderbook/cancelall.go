@@ -0,0 +1,63 @@
+package orderbook
+
+// Which side of the book CancelAll looks at
+type CancelSide int
+
+const (
+	CancelBoth CancelSide = iota
+	CancelBids
+	CancelAsks
+)
+
+// Narrows down what CancelAll cancels, the zero value cancels everything
+type CancelAllOptions struct {
+	Side     CancelSide
+	TraderID string  // only this trader's orders, empty means everyone's
+	MinPrice float64 // only orders priced at or above this, 0 means no lower bound
+	MaxPrice float64 // only orders priced at or below this, 0 means no upper bound
+}
+
+func (opts CancelAllOptions) matches(o *Order, price float64) bool {
+	if opts.TraderID != "" && o.TraderID != opts.TraderID {
+		return false
+	}
+	if opts.MinPrice > 0 && price < opts.MinPrice {
+		return false
+	}
+	if opts.MaxPrice > 0 && price > opts.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// Cancels every resting order that opts lets through, e.g. to pull all of a
+// market maker's quotes at once. Pending stops aren't on the book and are
+// left alone. Returns the ids of the cancelled orders, asks first, then bids,
+// each best price first.
+func (ob *Orderbook) CancelAll(opts CancelAllOptions) []int64 {
+	var toCancel []*Order
+
+	collect := func(limits []*Limit) {
+		for _, l := range limits {
+			for _, o := range l.Orders {
+				if opts.matches(o, l.Price) {
+					toCancel = append(toCancel, o)
+				}
+			}
+		}
+	}
+	if opts.Side != CancelBids {
+		collect(ob.Asks())
+	}
+	if opts.Side != CancelAsks {
+		collect(ob.Bids())
+	}
+
+	ids := make([]int64, 0, len(toCancel))
+	for _, o := range toCancel {
+		ob.cancel(o)
+		ids = append(ids, o.ID)
+	}
+
+	return ids
+}
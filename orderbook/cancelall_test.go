@@ -0,0 +1,68 @@
+package orderbook
+
+import "testing"
+
+func cancelAllBook() (*Orderbook, map[string]*Order) {
+	ob := NewOrderBook()
+	orders := map[string]*Order{
+		"alice ask 101": NewOrder(false, 1, WithTraderID("alice")),
+		"bob ask 101":   NewOrder(false, 2, WithTraderID("bob")),
+		"alice ask 103": NewOrder(false, 3, WithTraderID("alice")),
+		"alice bid 99":  NewOrder(true, 4, WithTraderID("alice")),
+		"bob bid 98":    NewOrder(true, 5, WithTraderID("bob")),
+	}
+	ob.PlaceLimitOrder(101, orders["alice ask 101"])
+	ob.PlaceLimitOrder(101, orders["bob ask 101"])
+	ob.PlaceLimitOrder(103, orders["alice ask 103"])
+	ob.PlaceLimitOrder(99, orders["alice bid 99"])
+	ob.PlaceLimitOrder(98, orders["bob bid 98"])
+	return ob, orders
+}
+
+func TestCancelAll(t *testing.T) {
+	ob, orders := cancelAllBook()
+
+	ids := ob.CancelAll(CancelAllOptions{})
+	assert(t, ids, []int64{
+		orders["alice ask 101"].ID,
+		orders["bob ask 101"].ID,
+		orders["alice ask 103"].ID,
+		orders["alice bid 99"].ID,
+		orders["bob bid 98"].ID,
+	})
+	assert(t, ob.NumOrders(), 0)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, len(ob.Bids()), 0)
+	assert(t, ob.AskTotalVolume(), 0.0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+}
+
+func TestCancelAllByTrader(t *testing.T) {
+	ob, orders := cancelAllBook()
+
+	ids := ob.CancelAll(CancelAllOptions{TraderID: "alice"})
+	assert(t, ids, []int64{
+		orders["alice ask 101"].ID,
+		orders["alice ask 103"].ID,
+		orders["alice bid 99"].ID,
+	})
+	assert(t, len(ob.OpenOrders("alice")), 0)
+	assert(t, len(ob.OpenOrders("bob")), 2)
+	assert(t, ob.AskTotalVolume(), 2.0)
+	assert(t, ob.BidTotalVolume(), 5.0)
+	assert(t, ob.BestAsk().Price, 101.0)
+	assert(t, ob.BestBid().Price, 98.0)
+	assert(t, orders["alice bid 99"].Status, StatusCancelled)
+}
+
+func TestCancelAllBySideAndPrice(t *testing.T) {
+	ob, orders := cancelAllBook()
+
+	ids := ob.CancelAll(CancelAllOptions{Side: CancelAsks, MinPrice: 102})
+	assert(t, ids, []int64{orders["alice ask 103"].ID})
+
+	ids = ob.CancelAll(CancelAllOptions{Side: CancelBids, MaxPrice: 98.5})
+	assert(t, ids, []int64{orders["bob bid 98"].ID})
+
+	assert(t, ob.NumOrders(), 3)
+}
@@ -0,0 +1,121 @@
+package orderbook
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Open, high, low and close of the trades in one interval
+type Candle struct {
+	Start  int64 // unix nanos the interval starts at, a multiple of the interval
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	Trades int
+}
+
+// Builds candles of a fixed interval from a book's trades as they happen.
+// A candle is finished once a trade lands past its interval or Tick is
+// called after it, and then goes out on Candles. Intervals nothing traded in
+// don't get a candle.
+type CandleStream struct {
+	interval int64
+
+	mu      sync.Mutex // the book adds trades under its own lock, readers come from anywhere
+	current Candle
+	open    bool // whether current has a trade in it
+	candles chan Candle
+	closed  bool
+}
+
+// Starts building candles of interval from every trade the book makes from
+// now on. Stop it with StopCandles. Panics if interval isn't positive, like
+// time.NewTicker.
+func (ob *Orderbook) StreamCandles(interval time.Duration) *CandleStream {
+	if interval <= 0 {
+		panic("non-positive interval for StreamCandles")
+	}
+	cs := &CandleStream{
+		interval: int64(interval),
+		candles:  make(chan Candle, feedBufferSize),
+	}
+	ob.candleStreams = append(ob.candleStreams, cs)
+	return cs
+}
+
+// Detaches the stream from the book and closes its Candles channel. The
+// candle in progress isn't sent.
+func (ob *Orderbook) StopCandles(cs *CandleStream) {
+	for i, s := range ob.candleStreams {
+		if s == cs {
+			ob.candleStreams = append(ob.candleStreams[:i], ob.candleStreams[i+1:]...)
+			break
+		}
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if !cs.closed {
+		cs.closed = true
+		close(cs.candles)
+	}
+}
+
+// Finished candles, oldest first. Like the feed, a reader that falls behind
+// by more than the buffer misses candles rather than holding up matching.
+func (cs *CandleStream) Candles() <-chan Candle {
+	return cs.candles
+}
+
+// The candle still being built, false when nothing traded in it yet
+func (cs *CandleStream) Current() (Candle, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.current, cs.open
+}
+
+// Finishes the candle in progress if now (unix nanos, book clock) is past its
+// interval, for when no trade comes along to do it
+func (cs *CandleStream) Tick(now int64) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.open && now >= cs.current.Start+cs.interval {
+		cs.finish()
+	}
+}
+
+func (cs *CandleStream) add(m Match) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	start := m.Timestamp - m.Timestamp%cs.interval
+	if cs.open && start != cs.current.Start {
+		cs.finish()
+	}
+
+	if !cs.open {
+		cs.current = Candle{Start: start, Open: m.Price, High: m.Price, Low: m.Price}
+		cs.open = true
+	}
+	cs.current.High = math.Max(cs.current.High, m.Price)
+	cs.current.Low = math.Min(cs.current.Low, m.Price)
+	cs.current.Close = m.Price
+	cs.current.Volume += m.SizeFilled
+	cs.current.Trades++
+}
+
+func (cs *CandleStream) finish() {
+	cs.open = false
+	if cs.closed {
+		return
+	}
+
+	select {
+	case cs.candles <- cs.current:
+	default: // reader is behind
+	}
+}
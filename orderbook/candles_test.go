@@ -0,0 +1,54 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCandleStream(t *testing.T) {
+	ob := NewOrderBook()
+	now := time.Unix(60, 0)
+	ob.SetClock(func() time.Time { return now })
+	cs := ob.StreamCandles(time.Minute)
+
+	_, ok := cs.Current()
+	assert(t, ok, false)
+
+	trade := func(at time.Time, price, size float64) {
+		now = at
+		ob.PlaceLimitOrder(price, NewOrder(false, size))
+		ob.PlaceMarketOrder(NewOrder(true, size))
+	}
+
+	// Three trades in the first minute, nothing finished yet
+	trade(time.Unix(60, 0), 100, 1)
+	trade(time.Unix(80, 0), 103, 2)
+	trade(time.Unix(119, 0), 99, 1)
+	assert(t, len(cs.Candles()), 0)
+
+	current, ok := cs.Current()
+	assert(t, ok, true)
+	assert(t, current, Candle{Start: int64(60 * time.Second), Open: 100, High: 103, Low: 99, Close: 99, Volume: 4, Trades: 3})
+
+	// A trade two minutes on finishes the first, the empty minute between gets nothing
+	trade(time.Unix(185, 0), 101, 1)
+	assert(t, <-cs.Candles(), current)
+	assert(t, len(cs.Candles()), 0)
+
+	current, _ = cs.Current()
+	assert(t, current, Candle{Start: int64(180 * time.Second), Open: 101, High: 101, Low: 101, Close: 101, Volume: 1, Trades: 1})
+
+	// No more trades, a tick past the end finishes it
+	cs.Tick(int64(239 * time.Second))
+	assert(t, len(cs.Candles()), 0)
+	cs.Tick(int64(240 * time.Second))
+	assert(t, <-cs.Candles(), current)
+	_, ok = cs.Current()
+	assert(t, ok, false)
+
+	ob.StopCandles(cs)
+	trade(time.Unix(300, 0), 100, 1)
+	_, open := <-cs.Candles()
+	assert(t, open, false)
+	ob.StopCandles(cs) // stopping twice is fine
+}
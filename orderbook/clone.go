@@ -0,0 +1,48 @@
+package orderbook
+
+import "time"
+
+// An independent copy of the book to experiment on: new limits and orders,
+// with every lookup and back-pointer rebuilt, so nothing done to the clone
+// touches the original. The configuration (the account checker too), clock,
+// logger and trading history come along. The tape's matches still point at
+// the original orders, history is shared rather than forked. A feed, journal,
+// rate limiter or expiry loop stays with the original.
+func (ob *Orderbook) Clone() *Orderbook {
+	clone := NewOrderBook()
+	clone.newLevelStore = ob.newLevelStore // restoring makes the stores over
+	clone.now = ob.now
+	clone.restore(ob.Snapshot())
+
+	if ob.Fees != nil {
+		fees := *ob.Fees
+		fees.Tiers = append([]FeeTier(nil), ob.Fees.Tiers...)
+		clone.Fees = &fees
+	}
+	if ob.Rounding != nil {
+		rounding := *ob.Rounding
+		clone.Rounding = &rounding
+	}
+	clone.PriceSampleRetention = ob.PriceSampleRetention
+	clone.TradeHistorySize = ob.TradeHistorySize
+	clone.TradeHistoryPolicy = ob.TradeHistoryPolicy
+	clone.logger = ob.logger
+	clone.Accounts = ob.Accounts
+
+	clone.trades = append([]Match(nil), ob.trades...)
+	clone.priceSamples = append([]priceSample(nil), ob.priceSamples...)
+	clone.levelSurvival = append([]time.Duration(nil), ob.levelSurvival...)
+	clone.tradeCount = ob.tradeCount
+	clone.tradedVolume = ob.tradedVolume
+	clone.tradedNotional = ob.tradedNotional
+
+	// Restoring starts every level's clock over, keep their real age
+	for price, l := range ob.AskLimits {
+		clone.AskLimits[price].createdAt = l.createdAt
+	}
+	for price, l := range ob.BidLimits {
+		clone.BidLimits[price].createdAt = l.createdAt
+	}
+
+	return clone
+}
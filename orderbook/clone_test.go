@@ -0,0 +1,40 @@
+package orderbook
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Fees = &FeeSchedule{Tiers: []FeeTier{{MakerRate: 0.001, TakerRate: 0.002}}}
+	ob.PlaceLimitOrder(101, NewOrder(false, 3, WithTraderID("alice")))
+	ob.PlaceLimitOrder(102, NewOrder(false, 1))
+	ob.PlaceLimitOrder(99, NewOrder(true, 2, WithTraderID("bob")))
+	ob.PlaceMarketOrder(NewOrder(true, 1))
+
+	clone := ob.Clone()
+	assert(t, clone.Snapshot(), ob.Snapshot())
+	assert(t, clone.Stats(), ob.Stats())
+	lastPrice, _ := clone.LastPrice()
+	assert(t, lastPrice, 101.0)
+
+	// Nothing is shared
+	assert(t, clone.BestAsk() != ob.BestAsk(), true)
+	assert(t, clone.BestAsk().Orders[0] != ob.BestAsk().Orders[0], true)
+	assert(t, clone.BestAsk().Orders[0].Limit, clone.BestAsk())
+	assert(t, clone.Fees != ob.Fees, true)
+
+	before := ob.Snapshot()
+	clone.PlaceMarketOrder(NewOrder(true, 3))
+	clone.PlaceLimitOrder(100, NewOrder(true, 5, WithTraderID("bob")))
+	clone.CancelOrder(clone.OpenOrders("bob")[0])
+	clone.Fees.Tiers[0].TakerRate = 0.01
+
+	assert(t, ob.Snapshot(), before)
+	assert(t, ob.AskTotalVolume(), 3.0)
+	assert(t, ob.BidTotalVolume(), 2.0)
+	assert(t, len(ob.Trades()), 1)
+	assert(t, len(ob.OpenOrders("bob")), 1)
+	assert(t, ob.Fees.Tiers[0].TakerRate, 0.002)
+
+	assert(t, clone.AskTotalVolume(), 0.0)
+	assert(t, len(clone.Trades()), 3)
+}
@@ -0,0 +1,21 @@
+package orderbook
+
+import "context"
+
+// Same as PlaceLimitOrder, but gives up without touching the book when ctx is
+// already cancelled or past its deadline. Matching itself is in memory and
+// quick, so it isn't interrupted halfway.
+func (ob *Orderbook) PlaceLimitOrderCtx(ctx context.Context, price float64, o *Order) ([]Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ob.PlaceLimitOrder(price, o)
+}
+
+// Same as PlaceMarketOrder, but gives up without touching the book when ctx is done
+func (ob *Orderbook) PlaceMarketOrderCtx(ctx context.Context, o *Order) ([]Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ob.PlaceMarketOrder(o)
+}
@@ -0,0 +1,42 @@
+package orderbook
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPlaceWithCancelledContext(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+	before := ob.String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	matches, err := ob.PlaceLimitOrderCtx(ctx, 100, NewOrder(true, 2))
+	assert(t, err, context.Canceled)
+	assert(t, len(matches), 0)
+
+	_, err = ob.PlaceMarketOrderCtx(ctx, NewOrder(true, 2))
+	assert(t, err, context.Canceled)
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = ob.PlaceLimitOrderCtx(ctx, 99, NewOrder(true, 2))
+	assert(t, err, context.DeadlineExceeded)
+
+	assert(t, ob.String(), before)
+	assert(t, ob.NumOrders(), 1)
+	assert(t, len(ob.Trades()), 0)
+}
+
+func TestPlaceWithLiveContext(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+	matches, err := ob.PlaceMarketOrderCtx(context.Background(), NewOrder(true, 2))
+	assert(t, err, nil)
+	assert(t, len(matches), 1)
+	assert(t, ob.AskTotalVolume(), 3.0)
+}
@@ -0,0 +1,298 @@
+package orderbook
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// The decimal mode of the book. Prices and sizes are decimal.Decimal, so
+// amounts like 0.1 + 0.2 come out exactly and chained partial fills never
+// drift. It mirrors the integer book. Decimals can't be map keys, so levels
+// are keyed on the price's canonical string (1.10 and 1.1 are the same level).
+
+type DecMatch struct {
+	Ask        *DecOrder
+	Bid        *DecOrder
+	SizeFilled decimal.Decimal
+	Price      decimal.Decimal
+	Timestamp  int64
+}
+
+type DecOrder struct {
+	ID        int64
+	Size      decimal.Decimal
+	Bid       bool
+	Limit     *DecLimit
+	Timestamp int64
+}
+
+func NewDecOrder(bid bool, size decimal.Decimal) *DecOrder {
+	return &DecOrder{
+		ID:        nextOrderID(),
+		Size:      size,
+		Bid:       bid,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+func (o *DecOrder) IsFilled() bool {
+	return o.Size.IsZero()
+}
+
+type DecLimit struct {
+	Price       decimal.Decimal
+	Orders      []*DecOrder
+	TotalVolume decimal.Decimal
+
+	book *DecOrderbook
+}
+
+func NewDecLimit(price decimal.Decimal) *DecLimit {
+	return &DecLimit{
+		Price:  price,
+		Orders: []*DecOrder{},
+	}
+}
+
+func (l *DecLimit) AddOrder(o *DecOrder) {
+	o.Limit = l
+	l.Orders = append(l.Orders, o)
+	l.addVolume(o.Bid, o.Size)
+}
+
+// Removes an order from the level, keeping the rest in time priority
+func (l *DecLimit) DeleteOrder(o *DecOrder) {
+	for i := 0; i < len(l.Orders); i++ {
+		if l.Orders[i] == o {
+			l.Orders = append(l.Orders[:i], l.Orders[i+1:]...)
+			break
+		}
+	}
+
+	o.Limit = nil
+	l.addVolume(o.Bid, o.Size.Neg())
+}
+
+func (l *DecLimit) Fill(o *DecOrder) []DecMatch {
+	var (
+		matches        []DecMatch
+		ordersToDelete []*DecOrder
+	)
+
+	for _, order := range l.Orders {
+		match := l.fillOrder(order, o)
+		matches = append(matches, match)
+
+		if order.IsFilled() {
+			ordersToDelete = append(ordersToDelete, order)
+		}
+
+		if o.IsFilled() {
+			break
+		}
+	}
+
+	for _, order := range ordersToDelete {
+		l.DeleteOrder(order)
+
+		if l.book != nil {
+			delete(l.book.Orders, order.ID)
+		}
+	}
+
+	return matches
+}
+
+func (l *DecLimit) fillOrder(a, b *DecOrder) DecMatch {
+	sizeFilled := decimal.Min(a.Size, b.Size)
+	a.Size = a.Size.Sub(sizeFilled)
+	b.Size = b.Size.Sub(sizeFilled)
+	l.addVolume(a.Bid, sizeFilled.Neg())
+
+	match := DecMatch{
+		SizeFilled: sizeFilled,
+		Price:      l.Price,
+		Timestamp:  time.Now().UnixNano(),
+	}
+	if a.Bid {
+		match.Bid, match.Ask = a, b
+	} else {
+		match.Bid, match.Ask = b, a
+	}
+
+	return match
+}
+
+func (l *DecLimit) addVolume(bid bool, delta decimal.Decimal) {
+	l.TotalVolume = l.TotalVolume.Add(delta)
+
+	if l.book == nil {
+		return
+	}
+	if bid {
+		l.book.bidVolume = l.book.bidVolume.Add(delta)
+	} else {
+		l.book.askVolume = l.book.askVolume.Add(delta)
+	}
+}
+
+type DecOrderbook struct {
+	asks []*DecLimit // best (lowest) price first
+	bids []*DecLimit // best (highest) price first
+
+	AskLimits map[string]*DecLimit // keyed on Price.String()
+	BidLimits map[string]*DecLimit
+	Orders    map[int64]*DecOrder
+
+	askVolume decimal.Decimal
+	bidVolume decimal.Decimal
+}
+
+func NewDecOrderBook() *DecOrderbook {
+	return &DecOrderbook{
+		asks:      []*DecLimit{},
+		bids:      []*DecLimit{},
+		AskLimits: make(map[string]*DecLimit),
+		BidLimits: make(map[string]*DecLimit),
+		Orders:    make(map[int64]*DecOrder),
+	}
+}
+
+// Fills as much of the order as the book allows, anything left over is dropped
+func (ob *DecOrderbook) PlaceMarketOrder(o *DecOrder) ([]DecMatch, error) {
+	if !o.Size.IsPositive() {
+		return nil, ErrInvalidSize
+	}
+
+	return ob.match(o, func(decimal.Decimal) bool { return true }), nil
+}
+
+// Matches the order against the other side and rests whatever is left
+func (ob *DecOrderbook) PlaceLimitOrder(price decimal.Decimal, o *DecOrder) ([]DecMatch, error) {
+	if !o.Size.IsPositive() {
+		return nil, ErrInvalidSize
+	}
+	if !price.IsPositive() {
+		return nil, ErrInvalidPrice
+	}
+
+	matches := ob.match(o, func(levelPrice decimal.Decimal) bool {
+		if o.Bid {
+			return price.GreaterThanOrEqual(levelPrice)
+		}
+		return price.LessThanOrEqual(levelPrice)
+	})
+
+	if !o.IsFilled() {
+		limit := ob.AskLimits[price.String()]
+		if o.Bid {
+			limit = ob.BidLimits[price.String()]
+		}
+		if limit == nil {
+			limit = NewDecLimit(price)
+			limit.book = ob
+			ob.addLimit(o.Bid, limit)
+		}
+
+		ob.Orders[o.ID] = o
+		limit.AddOrder(o)
+	}
+
+	return matches, nil
+}
+
+func (ob *DecOrderbook) match(o *DecOrder, canFill func(price decimal.Decimal) bool) []DecMatch {
+	matches := []DecMatch{}
+
+	for !o.IsFilled() {
+		side := *ob.levels(!o.Bid)
+		if len(side) == 0 || !canFill(side[0].Price) {
+			break
+		}
+		limit := side[0]
+
+		matches = append(matches, limit.Fill(o)...)
+		if len(limit.Orders) == 0 {
+			ob.clearLimit(!o.Bid, limit)
+		}
+	}
+
+	return matches
+}
+
+func (ob *DecOrderbook) CancelOrder(o *DecOrder) {
+	limit := o.Limit
+	if limit == nil {
+		return
+	}
+
+	limit.DeleteOrder(o)
+	delete(ob.Orders, o.ID)
+
+	if len(limit.Orders) == 0 {
+		ob.clearLimit(o.Bid, limit)
+	}
+}
+
+func (ob *DecOrderbook) levels(bid bool) *[]*DecLimit {
+	if bid {
+		return &ob.bids
+	}
+	return &ob.asks
+}
+
+func (ob *DecOrderbook) levelIndex(bid bool, price decimal.Decimal) int {
+	side := *ob.levels(bid)
+	return sort.Search(len(side), func(i int) bool {
+		if bid {
+			return side[i].Price.LessThanOrEqual(price)
+		}
+		return side[i].Price.GreaterThanOrEqual(price)
+	})
+}
+
+func (ob *DecOrderbook) addLimit(bid bool, l *DecLimit) {
+	if bid {
+		ob.BidLimits[l.Price.String()] = l
+	} else {
+		ob.AskLimits[l.Price.String()] = l
+	}
+
+	side := ob.levels(bid)
+	i := ob.levelIndex(bid, l.Price)
+	*side = append(*side, nil)
+	copy((*side)[i+1:], (*side)[i:])
+	(*side)[i] = l
+}
+
+func (ob *DecOrderbook) clearLimit(bid bool, l *DecLimit) {
+	if bid {
+		delete(ob.BidLimits, l.Price.String())
+	} else {
+		delete(ob.AskLimits, l.Price.String())
+	}
+
+	side := ob.levels(bid)
+	i := ob.levelIndex(bid, l.Price)
+	if i < len(*side) && (*side)[i] == l {
+		*side = append((*side)[:i], (*side)[i+1:]...)
+	}
+}
+
+func (ob *DecOrderbook) Asks() []*DecLimit {
+	return ob.asks
+}
+
+func (ob *DecOrderbook) Bids() []*DecLimit {
+	return ob.bids
+}
+
+func (ob *DecOrderbook) AskTotalVolume() decimal.Decimal {
+	return ob.askVolume
+}
+
+func (ob *DecOrderbook) BidTotalVolume() decimal.Decimal {
+	return ob.bidVolume
+}
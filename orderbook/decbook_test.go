@@ -0,0 +1,65 @@
+package orderbook
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestDecBookMatching(t *testing.T) {
+	ob := NewDecOrderBook()
+	a, b := NewDecOrder(false, dec("0.5")), NewDecOrder(false, dec("0.3"))
+	ob.PlaceLimitOrder(dec("101.10"), a)
+	ob.PlaceLimitOrder(dec("101.1"), b) // same level as 101.10
+	ob.PlaceLimitOrder(dec("102"), NewDecOrder(false, dec("0.4")))
+	assert(t, len(ob.Asks()), 2)
+
+	matches, err := ob.PlaceLimitOrder(dec("101.1"), NewDecOrder(true, dec("0.6")))
+	assert(t, err, nil)
+	assert(t, len(matches), 2)
+	assert(t, matches[0].Ask, a)
+	assert(t, matches[1].SizeFilled.Equal(dec("0.1")), true)
+	assert(t, b.Size.Equal(dec("0.2")), true)
+	assert(t, ob.AskTotalVolume().Equal(dec("0.6")), true)
+
+	matches, _ = ob.PlaceMarketOrder(NewDecOrder(true, dec("0.3")))
+	assert(t, len(matches), 2)
+	assert(t, matches[1].Price.Equal(dec("102")), true)
+	assert(t, len(ob.Asks()), 1)
+
+	bid := NewDecOrder(true, dec("0.2"))
+	ob.PlaceLimitOrder(dec("100"), bid)
+	ob.CancelOrder(bid)
+	assert(t, ob.BidTotalVolume().IsZero(), true)
+	assert(t, len(ob.Bids()), 0)
+
+	_, err = ob.PlaceLimitOrder(dec("-1"), NewDecOrder(true, dec("1")))
+	assert(t, err, ErrInvalidPrice)
+}
+
+// Ten fills of 0.1 use up an order of 1 exactly. In float64 a sliver of the
+// order is left resting on the book.
+func TestDecBookChainedPartialFills(t *testing.T) {
+	ob := NewDecOrderBook()
+	resting := NewDecOrder(false, dec("1"))
+	ob.PlaceLimitOrder(dec("100"), resting)
+	for i := 0; i < 10; i++ {
+		ob.PlaceMarketOrder(NewDecOrder(true, dec("0.1")))
+	}
+	assert(t, resting.IsFilled(), true)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, ob.AskTotalVolume().IsZero(), true)
+
+	fob := NewOrderBook()
+	fresting := NewOrder(false, 1)
+	fob.PlaceLimitOrder(100, fresting)
+	for i := 0; i < 10; i++ {
+		fob.PlaceMarketOrder(NewOrder(true, 0.1))
+	}
+	assert(t, fresting.IsFilled(), false)
+	assert(t, len(fob.Asks()), 1)
+}
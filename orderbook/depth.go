@@ -0,0 +1,159 @@
+package orderbook
+
+import (
+	"math"
+	"sort"
+)
+
+// How lopsided the book is, from -1 (only asks) to +1 (only bids). Only the
+// top levels price levels of each side count, 0 or less means the whole book.
+// An empty book is balanced, so 0.
+func (ob *Orderbook) Imbalance(levels int) float64 {
+	bidVol := topVolume(ob.Bids(), levels)
+	askVol := topVolume(ob.Asks(), levels)
+
+	if bidVol+askVol == 0 {
+		return 0
+	}
+	return (bidVol - askVol) / (bidVol + askVol)
+}
+
+func topVolume(limits []*Limit, levels int) float64 {
+	if levels > 0 && levels < len(limits) {
+		limits = limits[:levels]
+	}
+
+	total := 0.0
+	for _, l := range limits {
+		total += l.TotalVolume
+	}
+	return total
+}
+
+// The volume resting at exactly this price, on whichever side has it
+func (ob *Orderbook) VolumeAtPrice(price float64) float64 {
+	if l, ok := ob.AskLimits[price]; ok {
+		return l.TotalVolume
+	}
+	if l, ok := ob.BidLimits[price]; ok {
+		return l.TotalVolume
+	}
+	return 0
+}
+
+// The volume on one side from the best price out to and including price
+func (ob *Orderbook) CumulativeVolumeToPrice(bid bool, price float64) float64 {
+	total := 0.0
+	for _, l := range ob.limits(bid) {
+		if better(bid, price, l.Price) {
+			break
+		}
+		total += l.TotalVolume
+	}
+	return total
+}
+
+// How much a buy (bid) or sell would have to take from the other side to
+// trade its way to target, and what that costs in quote. Counts every level
+// from the best price out to and including target, all of it when target is
+// beyond the deepest level.
+func (ob *Orderbook) CostToReachPrice(bid bool, target float64) (size, notional float64) {
+	for _, l := range ob.limits(!bid) {
+		if better(!bid, target, l.Price) {
+			break
+		}
+		size += l.TotalVolume
+		notional += l.TotalVolume * l.Price
+	}
+	return size, notional
+}
+
+// The top n levels of each side with prices grouped into bins of binSize,
+// volumes summed per bin. Bids round down and asks round up, so a bin never
+// shows a better price than the book really has and the sides can't cross.
+// binSize <= 0 gives the raw levels, n <= 0 every bin.
+func (ob *Orderbook) AggregatedDepth(binSize float64, n int) (asks, bids []PriceLevel) {
+	return aggregateLevels(ob.Asks(), false, binSize, n), aggregateLevels(ob.Bids(), true, binSize, n)
+}
+
+func aggregateLevels(limits []*Limit, bid bool, binSize float64, n int) []PriceLevel {
+	levels := []PriceLevel{}
+
+	for _, l := range limits {
+		price := l.Price
+		if binSize > 0 {
+			// the epsilon keeps 0.3 / 0.1 = 2.9999999999999996 in bin 3
+			if bid {
+				price = math.Floor(price/binSize+1e-9) * binSize
+			} else {
+				price = math.Ceil(price/binSize-1e-9) * binSize
+			}
+		}
+
+		// Levels come best first, so a bin's levels are next to each other
+		if last := len(levels) - 1; last >= 0 && levels[last].Price == price {
+			levels[last].Volume += l.TotalVolume
+			continue
+		}
+		if n > 0 && len(levels) == n {
+			break
+		}
+		levels = append(levels, PriceLevel{Price: price, Volume: l.TotalVolume})
+	}
+
+	return levels
+}
+
+// What a client gets to see of a resting order. Iceberg reserves and who
+// owns the order stay private.
+type OrderView struct {
+	ID        int64
+	Size      float64
+	Timestamp int64
+}
+
+// The orders at one price level in priority order, nil if there's no level
+// on that side at price. The views are copies, safe to keep and hand out.
+func (ob *Orderbook) OrdersAtPrice(bid bool, price float64) []OrderView {
+	limit := ob.AskLimits[price]
+	if bid {
+		limit = ob.BidLimits[price]
+	}
+	if limit == nil {
+		return nil
+	}
+
+	views := make([]OrderView, 0, len(limit.Orders))
+	for _, o := range limit.Orders {
+		views = append(views, OrderView{ID: o.ID, Size: o.Size, Timestamp: o.Timestamp})
+	}
+	return views
+}
+
+// Counts the orders at this level by visible size. buckets are ascending
+// upper bounds: count i is for sizes above buckets[i-1] up to and including
+// buckets[i], and the extra count at the end is for anything bigger than the
+// last bucket.
+func (l *Limit) SizeHistogram(buckets []float64) []int {
+	counts := make([]int, len(buckets)+1)
+	l.countSizes(buckets, counts)
+	return counts
+}
+
+func (l *Limit) countSizes(buckets []float64, counts []int) {
+	for _, o := range l.Orders {
+		counts[sort.SearchFloat64s(buckets, o.Size)]++
+	}
+}
+
+// SizeHistogram for every resting order on both sides
+func (ob *Orderbook) SizeHistogram(buckets []float64) []int {
+	counts := make([]int, len(buckets)+1)
+	for _, bid := range []bool{false, true} {
+		ob.levels(bid).Iterate(func(l *Limit) bool {
+			l.countSizes(buckets, counts)
+			return true
+		})
+	}
+	return counts
+}
@@ -0,0 +1,150 @@
+package orderbook
+
+import "testing"
+
+func TestImbalance(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, ob.Imbalance(0), 0.0)
+
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(102, NewOrder(false, 5))
+	ob.PlaceLimitOrder(100, NewOrder(true, 3))
+	ob.PlaceLimitOrder(99, NewOrder(true, 3))
+
+	assert(t, ob.Imbalance(1), 0.5)  // (3 - 1) / (3 + 1)
+	assert(t, ob.Imbalance(2), 0.0)  // (6 - 6) / 12
+	assert(t, ob.Imbalance(0), 0.0)  // whole book
+	assert(t, ob.Imbalance(-1), 0.0) // whole book
+	assert(t, ob.Imbalance(10), 0.0) // more levels than the book has
+
+	ob.PlaceMarketOrder(NewOrder(true, 6))
+	assert(t, ob.Imbalance(0), 1.0)
+}
+
+func TestVolumeAtPrice(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(101, NewOrder(false, 2))
+	ob.PlaceLimitOrder(100, NewOrder(true, 4))
+
+	assert(t, ob.VolumeAtPrice(101), 3.0)
+	assert(t, ob.VolumeAtPrice(100), 4.0)
+	assert(t, ob.VolumeAtPrice(100.5), 0.0)
+}
+
+func TestCumulativeVolumeToPrice(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(102, NewOrder(false, 2))
+	ob.PlaceLimitOrder(104, NewOrder(false, 4))
+	ob.PlaceLimitOrder(100, NewOrder(true, 3))
+	ob.PlaceLimitOrder(98, NewOrder(true, 5))
+
+	assert(t, ob.CumulativeVolumeToPrice(false, 100), 0.0) // better than the best ask
+	assert(t, ob.CumulativeVolumeToPrice(false, 101), 1.0)
+	assert(t, ob.CumulativeVolumeToPrice(false, 103), 3.0) // no level at 103
+	assert(t, ob.CumulativeVolumeToPrice(false, 104), 7.0)
+	assert(t, ob.CumulativeVolumeToPrice(false, 1000), 7.0)
+
+	assert(t, ob.CumulativeVolumeToPrice(true, 100), 3.0)
+	assert(t, ob.CumulativeVolumeToPrice(true, 99), 3.0)
+	assert(t, ob.CumulativeVolumeToPrice(true, 98), 8.0)
+	assert(t, ob.CumulativeVolumeToPrice(true, 101), 0.0)
+}
+
+func TestCostToReachPrice(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(102, NewOrder(false, 2))
+	ob.PlaceLimitOrder(104, NewOrder(false, 4))
+	ob.PlaceLimitOrder(100, NewOrder(true, 3))
+	ob.PlaceLimitOrder(98, NewOrder(true, 5))
+
+	size, notional := ob.CostToReachPrice(true, 100.5) // below the best ask
+	assert(t, size, 0.0)
+	assert(t, notional, 0.0)
+
+	size, notional = ob.CostToReachPrice(true, 102)
+	assert(t, size, 3.0)
+	assert(t, notional, 101+2*102.0)
+
+	size, notional = ob.CostToReachPrice(true, 1000) // beyond the deepest ask
+	assert(t, size, 7.0)
+	assert(t, notional, 101+2*102+4*104.0)
+
+	size, notional = ob.CostToReachPrice(false, 99)
+	assert(t, size, 3.0)
+	assert(t, notional, 300.0)
+
+	size, _ = ob.CostToReachPrice(false, 1)
+	assert(t, size, 8.0)
+	assert(t, ob.AskTotalVolume(), 7.0) // nothing traded
+}
+
+func TestAggregatedDepth(t *testing.T) {
+	ob := NewOrderBook()
+	for _, price := range []float64{100.1, 100.4, 100.5, 100.6, 101.2} {
+		ob.PlaceLimitOrder(price, NewOrder(false, 1))
+	}
+	for _, price := range []float64{99.9, 99.5, 99.4, 98.2} {
+		ob.PlaceLimitOrder(price, NewOrder(true, 2))
+	}
+
+	asks, bids := ob.AggregatedDepth(0.5, 0)
+	assert(t, asks, []PriceLevel{{100.5, 3}, {101, 1}, {101.5, 1}})
+	assert(t, bids, []PriceLevel{{99.5, 4}, {99, 2}, {98, 2}})
+
+	asks, bids = ob.AggregatedDepth(1, 1)
+	assert(t, asks, []PriceLevel{{101, 4}})
+	assert(t, bids, []PriceLevel{{99, 6}})
+
+	// No binning, just the top levels
+	asks, bids = ob.AggregatedDepth(0, 2)
+	assert(t, asks, []PriceLevel{{100.1, 1}, {100.4, 1}})
+	assert(t, bids, []PriceLevel{{99.9, 2}, {99.5, 2}})
+
+	asks, bids = NewOrderBook().AggregatedDepth(0.5, 5)
+	assert(t, asks, []PriceLevel{})
+	assert(t, bids, []PriceLevel{})
+}
+
+func TestOrdersAtPrice(t *testing.T) {
+	ob := NewOrderBook()
+	first := NewOrder(false, 1)
+	iceberg := NewOrder(false, 10, WithDisplaySize(2))
+	ob.PlaceLimitOrder(101, first)
+	ob.PlaceLimitOrder(101, iceberg)
+	ob.PlaceLimitOrder(100, NewOrder(true, 4))
+
+	views := ob.OrdersAtPrice(false, 101)
+	assert(t, views, []OrderView{
+		{ID: first.ID, Size: 1, Timestamp: first.Timestamp},
+		{ID: iceberg.ID, Size: 2, Timestamp: iceberg.Timestamp}, // only the peak shows
+	})
+
+	// A copy, changing it doesn't reach the book
+	views[0].Size = 50
+	assert(t, first.Size, 1.0)
+
+	assert(t, len(ob.OrdersAtPrice(true, 100)), 1)
+	assert(t, ob.OrdersAtPrice(true, 101), []OrderView(nil)) // asks, not bids
+	assert(t, ob.OrdersAtPrice(false, 102), []OrderView(nil))
+}
+
+func TestSizeHistogram(t *testing.T) {
+	ob := NewOrderBook()
+	buckets := []float64{1, 5, 10}
+
+	for _, size := range []float64{0.5, 1, 3, 5, 50} {
+		ob.PlaceLimitOrder(101, NewOrder(false, size))
+	}
+	ob.PlaceLimitOrder(99, NewOrder(true, 7))
+	ob.PlaceLimitOrder(98, NewOrder(true, 20, WithDisplaySize(2))) // counts by its peak
+
+	assert(t, ob.BestAsk().SizeHistogram(buckets), []int{2, 2, 0, 1})
+	assert(t, ob.BestBid().SizeHistogram(buckets), []int{0, 0, 1, 0})
+	assert(t, ob.SizeHistogram(buckets), []int{2, 3, 1, 1})
+
+	assert(t, ob.SizeHistogram(nil), []int{7}) // no buckets, everything overflows
+	assert(t, NewOrderBook().SizeHistogram(buckets), []int{0, 0, 0, 0})
+}
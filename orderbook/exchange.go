@@ -0,0 +1,58 @@
+package orderbook
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrMarketNotFound = errors.New("market not found")
+
+// A set of independent order books, one per symbol
+type Exchange struct {
+	markets map[string]*Orderbook
+}
+
+func NewExchange() *Exchange {
+	return &Exchange{
+		markets: make(map[string]*Orderbook),
+	}
+}
+
+// Returns the book for a symbol, creating an empty one the first time
+func (ex *Exchange) GetOrCreateMarket(symbol string) *Orderbook {
+	ob, ok := ex.markets[symbol]
+	if !ok {
+		ob = NewOrderBook()
+		ex.markets[symbol] = ob
+	}
+	return ob
+}
+
+// Returns the book for a symbol, false if it was never created
+func (ex *Exchange) Market(symbol string) (*Orderbook, bool) {
+	ob, ok := ex.markets[symbol]
+	return ob, ok
+}
+
+// All symbols with a book, sorted
+func (ex *Exchange) Markets() []string {
+	symbols := make([]string, 0, len(ex.markets))
+	for symbol := range ex.markets {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func (ex *Exchange) PlaceLimitOrder(symbol string, price float64, o *Order) ([]Match, error) {
+	return ex.GetOrCreateMarket(symbol).PlaceLimitOrder(price, o)
+}
+
+// Market orders need liquidity, so unlike limit orders they don't create a market
+func (ex *Exchange) PlaceMarketOrder(symbol string, o *Order) ([]Match, error) {
+	ob, ok := ex.markets[symbol]
+	if !ok {
+		return nil, ErrMarketNotFound
+	}
+	return ob.PlaceMarketOrder(o)
+}
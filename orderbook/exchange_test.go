@@ -0,0 +1,43 @@
+package orderbook
+
+import "testing"
+
+func TestExchangeMarketsAreIsolated(t *testing.T) {
+	ex := NewExchange()
+
+	ex.PlaceLimitOrder("ETH", 2_000, NewOrder(false, 5))
+	ex.PlaceLimitOrder("BTC", 30_000, NewOrder(false, 1))
+
+	assert(t, ex.Markets(), []string{"BTC", "ETH"})
+
+	// A bid on BTC at the ETH ask price must not trade with ETH
+	matches, err := ex.PlaceLimitOrder("BTC", 2_000, NewOrder(true, 1))
+	assert(t, err, nil)
+	assert(t, len(matches), 0)
+
+	matches, err = ex.PlaceMarketOrder("ETH", NewOrder(true, 2))
+	assert(t, err, nil)
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Price, 2_000.0)
+
+	eth, _ := ex.Market("ETH")
+	btc, _ := ex.Market("BTC")
+	assert(t, eth.AskTotalVolume(), 3.0)
+	assert(t, eth.BidTotalVolume(), 0.0)
+	assert(t, btc.AskTotalVolume(), 1.0)
+	assert(t, btc.BidTotalVolume(), 1.0)
+}
+
+func TestExchangeGetOrCreateMarket(t *testing.T) {
+	ex := NewExchange()
+
+	ob := ex.GetOrCreateMarket("ETH")
+	assert(t, ex.GetOrCreateMarket("ETH") == ob, true)
+
+	_, ok := ex.Market("SOL")
+	assert(t, ok, false)
+
+	_, err := ex.PlaceMarketOrder("SOL", NewOrder(true, 1))
+	assert(t, err, ErrMarketNotFound)
+	assert(t, ex.Markets(), []string{"ETH"})
+}
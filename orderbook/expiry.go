@@ -0,0 +1,108 @@
+package orderbook
+
+import "time"
+
+// Makes the order good till date, it is cancelled once expireAt (unix nanos) has passed
+func WithGoodTillDate(expireAt int64) OrderOption {
+	return func(o *Order) {
+		o.TimeInForce = GTD
+		o.ExpireAt = expireAt
+	}
+}
+
+func (o *Order) expired(now int64) bool {
+	return o.ExpireAt > 0 && o.ExpireAt <= now
+}
+
+// Cancels every resting order, pending stop and scheduled order whose
+// ExpireAt is at or before now (unix nanos) and returns their ids, book
+// orders first (asks, then bids, best price first), then the stops, then the
+// scheduled orders.
+func (ob *Orderbook) ExpireOrders(now int64) []int64 {
+	var expired []*Order
+
+	for _, limits := range [][]*Limit{ob.Asks(), ob.Bids()} {
+		for _, l := range limits {
+			for _, o := range l.Orders {
+				if o.expired(now) {
+					expired = append(expired, o)
+				}
+			}
+		}
+	}
+	for _, o := range append(ob.stops, ob.scheduled...) {
+		if o.expired(now) {
+			expired = append(expired, o)
+		}
+	}
+
+	ids := make([]int64, 0, len(expired))
+	for _, o := range expired {
+		ob.cancel(o)
+		ids = append(ids, o.ID)
+	}
+
+	return ids
+}
+
+// The book isn't safe for concurrent use by itself. Once the expiry loop
+// runs, wrap every call into the book in Lock/Unlock, the loop holds the
+// same lock while it expires orders. The only calls that take the lock
+// themselves are PlaceBatch and Ticker, don't call those while holding it.
+func (ob *Orderbook) Lock() {
+	ob.mu.Lock()
+}
+
+func (ob *Orderbook) Unlock() {
+	ob.mu.Unlock()
+}
+
+// Starts a goroutine that calls ActivateDue and then ExpireOrders with the
+// book's clock every interval until Close is called. Starting it twice does
+// nothing.
+func (ob *Orderbook) StartExpiryLoop(interval time.Duration) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if ob.expiryStop != nil {
+		return
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	ob.expiryStop, ob.expiryDone = stop, done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ob.mu.Lock()
+				now := ob.now().UnixNano()
+				ob.ActivateDue(now)
+				ob.ExpireOrders(now)
+				ob.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stops the expiry loop and waits for it to finish. Safe to call when no
+// loop is running.
+func (ob *Orderbook) Close() {
+	ob.mu.Lock()
+	stop, done := ob.expiryStop, ob.expiryDone
+	ob.expiryStop, ob.expiryDone = nil, nil
+	ob.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
@@ -0,0 +1,61 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpireOrders(t *testing.T) {
+	ob := NewOrderBook()
+	early := NewOrder(false, 1, WithGoodTillDate(100))
+	late := NewOrder(false, 2, WithGoodTillDate(200))
+	forever := NewOrder(true, 3)
+	stop := NewOrder(true, 1, WithStopPrice(110), WithGoodTillDate(150))
+	ob.PlaceLimitOrder(101, early)
+	ob.PlaceLimitOrder(102, late)
+	ob.PlaceLimitOrder(99, forever)
+	ob.PlaceStopOrder(stop)
+
+	assert(t, len(ob.ExpireOrders(99)), 0)
+
+	assert(t, ob.ExpireOrders(100), []int64{early.ID})
+	assert(t, early.Status, StatusCancelled)
+	assert(t, ob.BestAsk().Price, 102.0)
+
+	assert(t, ob.ExpireOrders(1_000), []int64{late.ID, stop.ID})
+	assert(t, len(ob.Asks()), 0)
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, ob.NumOrders(), 1)
+	assert(t, forever.Status, StatusNew)
+}
+
+func TestExpiryLoop(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetClock(func() time.Time { return time.Unix(0, 500) })
+	gtd := NewOrder(false, 1, WithGoodTillDate(400))
+	ob.PlaceLimitOrder(101, gtd)
+	ob.PlaceLimitOrder(102, NewOrder(false, 1, WithGoodTillDate(600)))
+
+	ob.StartExpiryLoop(time.Millisecond)
+	ob.StartExpiryLoop(time.Millisecond) // no second loop
+	defer ob.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ob.Lock()
+		n := ob.NumOrders()
+		ob.Unlock()
+
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expiry loop never expired the order")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ob.Close()
+	ob.Close() // closing twice is fine
+	assert(t, gtd.Status, StatusCancelled)
+}
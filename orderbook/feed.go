@@ -0,0 +1,160 @@
+package orderbook
+
+// How many messages the feed holds for a slow reader before it starts dropping.
+// Dropped messages still use up a sequence number so readers can spot the gap.
+const feedBufferSize = 1024
+
+type FeedMessageType int
+
+const (
+	FeedDepth FeedMessageType = iota // a price level changed
+	FeedTrade                        // a match was printed to the tape
+	FeedOrder                        // a resting order was added, changed or removed, see OrderEvents
+)
+
+// New total volume of a price level, 0 means the level is gone
+type LevelUpdate struct {
+	Bid       bool
+	Price     float64
+	NewVolume float64
+}
+
+// One message of the combined book and trade feed. Depth is set for
+// FeedDepth messages, Trade for FeedTrade messages, Order for FeedOrder messages.
+type FeedMessage struct {
+	Seq   int64
+	Type  FeedMessageType
+	Depth LevelUpdate
+	Trade Match
+	Order OrderEvent
+}
+
+type touchedLevel struct {
+	bid       bool
+	limit     *Limit
+	oldVolume float64
+}
+
+// Returns the combined feed of depth updates and trades in the order they
+// happened. Trades of an operation come before the depth changes they caused.
+func (ob *Orderbook) Feed() <-chan FeedMessage {
+	if ob.feed == nil {
+		ob.feed = make(chan FeedMessage, feedBufferSize)
+	}
+	return ob.feed
+}
+
+func (ob *Orderbook) publish(msg FeedMessage) {
+	if ob.feed == nil {
+		return
+	}
+
+	ob.feedSeq++
+	msg.Seq = ob.feedSeq
+
+	select {
+	case ob.feed <- msg:
+	default: // reader is behind, drop it rather than block matching
+	}
+}
+
+func (ob *Orderbook) publishTrade(m Match) {
+	ob.publish(FeedMessage{Type: FeedTrade, Trade: m})
+}
+
+// Marks a level as changed by the current operation. Call it before changing
+// the level so its volume from before the change is kept.
+func (ob *Orderbook) touch(bid bool, l *Limit) {
+	for _, t := range ob.touched {
+		if t.limit == l {
+			return
+		}
+	}
+	ob.touched = append(ob.touched, touchedLevel{bid: bid, limit: l, oldVolume: l.TotalVolume})
+}
+
+// Publishes the new volume of every level touched since the last flush,
+// reports a new top of book and records depth. Called as each operation finishes.
+func (ob *Orderbook) flushDepth() {
+	for _, t := range ob.touched {
+		update := ob.levelUpdate(t)
+		ob.publish(FeedMessage{Type: FeedDepth, Depth: update})
+		ob.recordLevelChange(t.oldVolume, update)
+	}
+	ob.touched = ob.touched[:0]
+
+	ob.notifyTopOfBook()
+	ob.recordDepth()
+}
+
+func (ob *Orderbook) levelUpdate(t touchedLevel) LevelUpdate {
+	limits := ob.AskLimits
+	if t.bid {
+		limits = ob.BidLimits
+	}
+
+	update := LevelUpdate{Bid: t.bid, Price: t.limit.Price}
+	if limits[t.limit.Price] == t.limit {
+		update.NewVolume = t.limit.TotalVolume
+	}
+	return update
+}
+
+// One price level in a Depth snapshot
+type PriceLevel struct {
+	Price  float64
+	Volume float64
+}
+
+// The visible levels of the book, best price first, as of feed message Seq.
+// A local mirror starts from this and applies every FeedDepth message with a
+// higher Seq, a jump in Seq means messages were dropped and it should resync.
+type Depth struct {
+	Seq  int64
+	Asks []PriceLevel
+	Bids []PriceLevel
+}
+
+func (ob *Orderbook) Depth() Depth {
+	return Depth{
+		Seq:  ob.feedSeq,
+		Asks: priceLevels(ob.Asks()),
+		Bids: priceLevels(ob.Bids()),
+	}
+}
+
+func priceLevels(limits []*Limit) []PriceLevel {
+	levels := make([]PriceLevel, len(limits))
+	for i, l := range limits {
+		levels[i] = PriceLevel{Price: l.Price, Volume: l.TotalVolume}
+	}
+	return levels
+}
+
+// Depth for readers that poll: writes the best len(askBuf) asks and
+// len(bidBuf) bids into the caller's buffers and returns how many of each it
+// wrote. Doesn't allocate with the default slice level store.
+func (ob *Orderbook) DepthInto(askBuf, bidBuf []PriceLevel) (nAsks, nBids int) {
+	return ob.levelsInto(false, askBuf), ob.levelsInto(true, bidBuf)
+}
+
+func (ob *Orderbook) levelsInto(bid bool, buf []PriceLevel) int {
+	if s, ok := ob.levels(bid).(*sliceLevels); ok {
+		n := min(len(buf), len(s.levels))
+		for i, l := range s.levels[:n] {
+			buf[i] = PriceLevel{Price: l.Price, Volume: l.TotalVolume}
+		}
+		return n
+	}
+
+	n := 0
+	ob.levels(bid).Iterate(func(l *Limit) bool {
+		if n == len(buf) {
+			return false
+		}
+		buf[n] = PriceLevel{Price: l.Price, Volume: l.TotalVolume}
+		n++
+		return true
+	})
+	return n
+}
@@ -0,0 +1,177 @@
+package orderbook
+
+import "testing"
+
+func drainFeed(feed <-chan FeedMessage) []FeedMessage {
+	msgs := []FeedMessage{}
+	for {
+		select {
+		case msg := <-feed:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	ob := NewOrderBook()
+	feed := ob.Feed()
+
+	sellA := NewOrder(false, 5)
+	sellB := NewOrder(false, 5)
+	ob.PlaceLimitOrder(10_000, sellA)
+	ob.PlaceLimitOrder(10_100, sellB)
+	ob.PlaceLimitOrder(10_000, NewOrder(true, 5)) // takes out the whole 10,000 level
+	ob.CancelOrder(sellB)
+
+	msgs := drainFeed(feed)
+	assert(t, len(msgs), 5)
+
+	for i, msg := range msgs {
+		assert(t, msg.Seq, int64(i+1))
+	}
+
+	assert(t, msgs[0].Type, FeedDepth)
+	assert(t, msgs[0].Depth, LevelUpdate{Bid: false, Price: 10_000, NewVolume: 5})
+	assert(t, msgs[1].Depth, LevelUpdate{Bid: false, Price: 10_100, NewVolume: 5})
+
+	assert(t, msgs[2].Type, FeedTrade)
+	assert(t, msgs[2].Trade.Ask, sellA)
+	assert(t, msgs[2].Trade.SizeFilled, 5.0)
+
+	assert(t, msgs[3].Type, FeedDepth)
+	assert(t, msgs[3].Depth, LevelUpdate{Bid: false, Price: 10_000, NewVolume: 0})
+
+	assert(t, msgs[4].Type, FeedDepth)
+	assert(t, msgs[4].Depth, LevelUpdate{Bid: false, Price: 10_100, NewVolume: 0})
+}
+
+func TestFeedPartialFillAndRest(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 3))
+
+	feed := ob.Feed()
+	ob.PlaceLimitOrder(10_000, NewOrder(true, 5))
+
+	msgs := drainFeed(feed)
+	assert(t, len(msgs), 3)
+	assert(t, msgs[0].Type, FeedTrade)
+	assert(t, msgs[1].Depth, LevelUpdate{Bid: false, Price: 10_000, NewVolume: 0})
+	assert(t, msgs[2].Depth, LevelUpdate{Bid: true, Price: 10_000, NewVolume: 2})
+}
+
+// A client side copy of the book kept up to date from depth updates
+type depthMirror struct {
+	seq  int64
+	asks map[float64]float64
+	bids map[float64]float64
+}
+
+func newDepthMirror(d Depth) *depthMirror {
+	m := &depthMirror{seq: d.Seq, asks: map[float64]float64{}, bids: map[float64]float64{}}
+	for _, l := range d.Asks {
+		m.asks[l.Price] = l.Volume
+	}
+	for _, l := range d.Bids {
+		m.bids[l.Price] = l.Volume
+	}
+	return m
+}
+
+func (m *depthMirror) apply(t *testing.T, msg FeedMessage) {
+	if msg.Seq <= m.seq {
+		return // already part of the snapshot
+	}
+	if msg.Seq != m.seq+1 {
+		t.Fatalf("gap in the feed: %d after %d", msg.Seq, m.seq)
+	}
+	m.seq = msg.Seq
+	if msg.Type != FeedDepth {
+		return
+	}
+
+	side := m.asks
+	if msg.Depth.Bid {
+		side = m.bids
+	}
+	if msg.Depth.NewVolume == 0 {
+		delete(side, msg.Depth.Price)
+	} else {
+		side[msg.Depth.Price] = msg.Depth.NewVolume
+	}
+}
+
+func TestDepthMirror(t *testing.T) {
+	ob := NewOrderBook()
+	feed := ob.Feed()
+	ob.PlaceLimitOrder(101, NewOrder(false, 3))
+	ob.PlaceLimitOrder(99, NewOrder(true, 3))
+
+	mirror := newDepthMirror(ob.Depth())
+	for _, msg := range drainFeed(feed) {
+		mirror.apply(t, msg) // all of these are in the snapshot already
+	}
+
+	var resting []*Order
+	for i := 0; i < 300; i++ {
+		price := float64(95 + i%11)
+		switch i % 4 {
+		case 0, 1:
+			o := NewOrder(i%3 == 0, float64(1+i%5), WithDisplaySize(2))
+			ob.PlaceLimitOrder(price, o)
+			resting = append(resting, o)
+		case 2:
+			if o := resting[i%len(resting)]; o.Limit != nil {
+				ob.CancelOrder(o)
+			}
+		case 3:
+			if ob.AskTotalVolume() >= 2 {
+				ob.PlaceMarketOrder(NewOrder(true, 2))
+			}
+		}
+
+		for _, msg := range drainFeed(feed) {
+			mirror.apply(t, msg)
+		}
+		depth := newDepthMirror(ob.Depth())
+		assert(t, mirror.asks, depth.asks)
+		assert(t, mirror.bids, depth.bids)
+		assert(t, mirror.seq, depth.seq)
+	}
+}
+
+func TestDepthInto(t *testing.T) {
+	ob := NewOrderBookWithLevelStore(NewSliceLevelStore)
+	for i := 0; i < 5; i++ {
+		ob.PlaceLimitOrder(float64(100+i), NewOrder(false, float64(i+1)))
+		ob.PlaceLimitOrder(float64(99-i), NewOrder(true, float64(i+1)))
+	}
+	depth := ob.Depth()
+
+	askBuf, bidBuf := make([]PriceLevel, 3), make([]PriceLevel, 10)
+	nAsks, nBids := ob.DepthInto(askBuf, bidBuf)
+	assert(t, nAsks, 3)
+	assert(t, nBids, 5)
+	assert(t, askBuf, depth.Asks[:3])
+	assert(t, bidBuf[:nBids], depth.Bids)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ob.DepthInto(askBuf, bidBuf)
+	})
+	assert(t, allocs, 0.0)
+}
+
+func BenchmarkDepthInto(b *testing.B) {
+	ob := NewOrderBookWithLevelStore(NewSliceLevelStore)
+	for _, price := range benchPrices() {
+		ob.PlaceLimitOrder(price+1, NewOrder(false, 1))
+	}
+	buf := make([]PriceLevel, 20)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ob.DepthInto(buf, buf)
+	}
+}
@@ -0,0 +1,93 @@
+package orderbook
+
+import "time"
+
+// Volume window used to pick fee tiers when the schedule doesn't set one
+const DefaultFeeWindow = 30 * 24 * time.Hour
+
+// Rates charged once a trader has traded at least MinVolume inside the fee
+// window. A negative rate is a rebate paid to the trader.
+type FeeTier struct {
+	MinVolume float64
+	MakerRate float64
+	TakerRate float64
+}
+
+type FeeSchedule struct {
+	Tiers  []FeeTier
+	Window time.Duration // 0 means DefaultFeeWindow
+}
+
+func (fs *FeeSchedule) window() time.Duration {
+	if fs.Window <= 0 {
+		return DefaultFeeWindow
+	}
+	return fs.Window
+}
+
+// Picks the best tier the volume qualifies for. Tiers don't have to be sorted.
+func (fs *FeeSchedule) TierFor(volume float64) FeeTier {
+	var (
+		best  FeeTier
+		found bool
+	)
+
+	for _, tier := range fs.Tiers {
+		if volume >= tier.MinVolume && (!found || tier.MinVolume > best.MinVolume) {
+			best = tier
+			found = true
+		}
+	}
+
+	return best
+}
+
+// Fills in the maker and taker fees of a match based on each trader's recent volume
+func (ob *Orderbook) chargeFees(m *Match, maker, taker *Order) {
+	if ob.Fees == nil {
+		return
+	}
+
+	notional := m.SizeFilled * m.Price
+	window := ob.Fees.window()
+
+	makerTier := ob.Fees.TierFor(ob.UserVolume(maker.TraderID, window))
+	takerTier := ob.Fees.TierFor(ob.UserVolume(taker.TraderID, window))
+
+	m.MakerFee = notional * makerTier.MakerRate
+	m.TakerFee = notional * takerTier.TakerRate
+
+	ob.accrue(maker.TraderID, m.MakerFee)
+	ob.accrue(taker.TraderID, m.TakerFee)
+}
+
+type feeAccrual struct {
+	fees    float64
+	rebates float64
+}
+
+// Adds a fee to what the trader owes, a negative fee is a rebate they're owed
+func (ob *Orderbook) accrue(traderID string, fee float64) {
+	if traderID == "" || fee == 0 {
+		return
+	}
+	if ob.accrued == nil {
+		ob.accrued = make(map[string]feeAccrual)
+	}
+
+	a := ob.accrued[traderID]
+	if fee > 0 {
+		a.fees += fee
+	} else {
+		a.rebates -= fee
+	}
+	ob.accrued[traderID] = a
+}
+
+// What a trader has been charged and what they've earned in rebates so far,
+// both as positive amounts in quote currency. fees - rebates is what they
+// owe, a negative result means the exchange owes them.
+func (ob *Orderbook) AccruedFees(traderID string) (fees, rebates float64) {
+	a := ob.accrued[traderID]
+	return a.fees, a.rebates
+}
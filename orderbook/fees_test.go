@@ -0,0 +1,110 @@
+package orderbook
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFeeTierUpgrade(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Fees = &FeeSchedule{
+		Tiers: []FeeTier{
+			{MinVolume: 0, MakerRate: 0.001, TakerRate: 0.002},
+			{MinVolume: 10, MakerRate: 0.0005, TakerRate: 0.001},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		ob.PlaceLimitOrder(100, NewOrder(false, 6, WithTraderID("maker")))
+	}
+
+	first, _ := ob.PlaceMarketOrder(NewOrder(true, 6, WithTraderID("alice")))
+	assert(t, first[0].TakerFee, 600*0.002)
+	assert(t, ob.UserVolume("alice", DefaultFeeWindow), 6.0)
+
+	second, _ := ob.PlaceMarketOrder(NewOrder(true, 6, WithTraderID("alice")))
+	assert(t, second[0].TakerFee, 600*0.002) // still 6 traded when this fill happened
+	assert(t, ob.UserVolume("alice", DefaultFeeWindow), 12.0)
+
+	third, _ := ob.PlaceMarketOrder(NewOrder(true, 6, WithTraderID("alice")))
+	assert(t, third[0].TakerFee, 600*0.001)
+	assert(t, third[0].MakerFee, 600*0.0005) // the maker crossed the boundary too
+}
+
+func TestUserVolumeWindow(t *testing.T) {
+	ob := NewOrderBook()
+	now := time.Unix(0, 0)
+	ob.SetClock(func() time.Time { return now })
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 10, WithTraderID("maker")))
+	ob.PlaceMarketOrder(NewOrder(true, 4, WithTraderID("alice")))
+
+	now = now.Add(2 * time.Hour)
+	ob.PlaceMarketOrder(NewOrder(true, 3, WithTraderID("alice")))
+
+	assert(t, ob.UserVolume("alice", 3*time.Hour), 7.0)
+	assert(t, ob.UserVolume("alice", time.Hour), 3.0)
+	assert(t, ob.UserVolume("maker", 3*time.Hour), 7.0)
+	assert(t, ob.UserVolume("bob", 3*time.Hour), 0.0)
+	assert(t, len(ob.Trades()), 2)
+}
+
+func TestFeeTierSurvivesDrain(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TradeHistorySize = 1
+	ob.TradeHistoryPolicy = ErrorWhenFull
+	ob.Fees = &FeeSchedule{
+		Tiers: []FeeTier{
+			{MinVolume: 0, TakerRate: 0.002},
+			{MinVolume: 10, TakerRate: 0.001},
+		},
+	}
+	ob.PlaceLimitOrder(100, NewOrder(false, 20, WithTraderID("maker")))
+
+	ob.PlaceMarketOrder(NewOrder(true, 10, WithTraderID("alice")))
+	ob.DrainTrades() // flushed to storage, as ErrorWhenFull asks
+	assert(t, ob.UserVolume("alice", DefaultFeeWindow), 10.0)
+
+	matches, _ := ob.PlaceMarketOrder(NewOrder(true, 1, WithTraderID("alice")))
+	assert(t, matches[0].TakerFee, 100*0.001)
+}
+
+func TestUserVolumeWindowPruning(t *testing.T) {
+	ob := NewOrderBook()
+	now := time.Unix(0, 0)
+	ob.SetClock(func() time.Time { return now })
+	ob.Fees = &FeeSchedule{Tiers: []FeeTier{{}}, Window: time.Hour}
+	ob.PlaceLimitOrder(100, NewOrder(false, 20, WithTraderID("maker")))
+
+	ob.PlaceMarketOrder(NewOrder(true, 1, WithTraderID("alice")))
+	now = now.Add(2 * time.Hour)
+	ob.PlaceMarketOrder(NewOrder(true, 2, WithTraderID("alice")))
+
+	assert(t, len(ob.userFills["alice"]), 1) // the first fill left the fee window
+	assert(t, ob.UserVolume("alice", time.Hour), 2.0)
+}
+
+func TestAccruedFees(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Fees = &FeeSchedule{
+		Tiers: []FeeTier{{MakerRate: -0.0001, TakerRate: 0.0005}},
+	}
+
+	for i := 0; i < 3; i++ {
+		ob.PlaceLimitOrder(100, NewOrder(false, 10, WithTraderID("maker")))
+		ob.PlaceMarketOrder(NewOrder(true, 10, WithTraderID("taker")))
+	}
+
+	fees, rebates := ob.AccruedFees("maker")
+	assert(t, fees, 0.0)
+	assert(t, math.Abs(rebates-3*1000*0.0001) < 1e-9, true)
+
+	fees, rebates = ob.AccruedFees("taker")
+	assert(t, math.Abs(fees-3*1000*0.0005) < 1e-9, true)
+	assert(t, rebates, 0.0)
+
+	fees, rebates = ob.AccruedFees("nobody")
+	assert(t, fees, 0.0)
+	assert(t, rebates, 0.0)
+}
@@ -0,0 +1,124 @@
+package orderbook
+
+import "container/heap"
+
+// A LevelStore keeping its side as a heap on price, so adding and removing a
+// level is O(log n) however deep the book is, where the sorted slice has to
+// shift everything behind the new level. Walking the side in price order is
+// the price: each walk after a change pops a copy of the heap, O(n) to copy
+// plus O(log n) per level visited. Matching walks the side for every order
+// that crosses, so the slice stays the default, see BenchmarkLevelStores.
+// Pick the heap with NewOrderBookWithLevelStore(NewHeapLevelStore) for books
+// that add and drop far away levels a lot more than they trade through them.
+type heapLevels struct {
+	bid     bool
+	heap    []*Limit // best price at index 0
+	byPrice map[float64]*Limit
+	sorted  []*Limit // best price first after a full walk, nil once a change made it stale
+	changes int      // bumped by every Insert and Remove
+}
+
+func NewHeapLevelStore(bid bool) LevelStore {
+	return &heapLevels{bid: bid, byPrice: make(map[float64]*Limit)}
+}
+
+func (h *heapLevels) BestLimit() *Limit {
+	if len(h.heap) == 0 {
+		return nil
+	}
+	return h.heap[0]
+}
+
+func (h *heapLevels) Insert(l *Limit) {
+	h.byPrice[l.Price] = l
+	h.sorted = nil
+	h.changes++
+	heap.Push((*levelHeap)(h), l)
+}
+
+func (h *heapLevels) Remove(l *Limit) {
+	if h.byPrice[l.Price] != l {
+		return
+	}
+	delete(h.byPrice, l.Price)
+	h.sorted = nil
+	h.changes++
+	heap.Remove((*levelHeap)(h), l.index)
+}
+
+func (h *heapLevels) Get(price float64) *Limit {
+	return h.byPrice[price]
+}
+
+func (h *heapLevels) Iterate(fn func(l *Limit) bool) {
+	// fn can change the side (matching clears levels), so walk what it was
+	if h.sorted != nil {
+		for _, l := range h.sorted {
+			if !fn(l) {
+				return
+			}
+		}
+		return
+	}
+
+	walk := &walkHeap{bid: h.bid, levels: append([]*Limit(nil), h.heap...)}
+	sorted := make([]*Limit, 0, len(h.heap))
+	changes := h.changes
+	for walk.Len() > 0 {
+		l := heap.Pop(walk).(*Limit)
+		sorted = append(sorted, l)
+		if !fn(l) {
+			return
+		}
+	}
+	if h.changes == changes {
+		h.sorted = sorted // walked it all, the next walk is free
+	}
+}
+
+func (h *heapLevels) Len() int {
+	return len(h.heap)
+}
+
+// heap.Interface for a heapLevels. Swap keeps each limit's index current so a
+// level can be removed in O(log n).
+type levelHeap heapLevels
+
+func (h *levelHeap) Len() int           { return len(h.heap) }
+func (h *levelHeap) Less(i, j int) bool { return better(h.bid, h.heap[i].Price, h.heap[j].Price) }
+func (h *levelHeap) Swap(i, j int) {
+	h.heap[i], h.heap[j] = h.heap[j], h.heap[i]
+	h.heap[i].index = i
+	h.heap[j].index = j
+}
+
+func (h *levelHeap) Push(x any) {
+	l := x.(*Limit)
+	l.index = len(h.heap)
+	h.heap = append(h.heap, l)
+}
+
+func (h *levelHeap) Pop() any {
+	l := h.heap[len(h.heap)-1]
+	h.heap[len(h.heap)-1] = nil
+	h.heap = h.heap[:len(h.heap)-1]
+	return l
+}
+
+// A throwaway copy of a heapLevels' heap to pop in price order, leaving the
+// limits' indexes alone
+type walkHeap struct {
+	bid    bool
+	levels []*Limit
+}
+
+func (h *walkHeap) Len() int           { return len(h.levels) }
+func (h *walkHeap) Less(i, j int) bool { return better(h.bid, h.levels[i].Price, h.levels[j].Price) }
+func (h *walkHeap) Swap(i, j int)      { h.levels[i], h.levels[j] = h.levels[j], h.levels[i] }
+func (h *walkHeap) Push(x any)         { h.levels = append(h.levels, x.(*Limit)) }
+
+func (h *walkHeap) Pop() any {
+	l := h.levels[len(h.levels)-1]
+	h.levels = h.levels[:len(h.levels)-1]
+	return l
+}
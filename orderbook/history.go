@@ -0,0 +1,66 @@
+package orderbook
+
+import "errors"
+
+var ErrSeqNotRetained = errors.New("sequence number is outside the retained history")
+
+// History is kept in segments: a copy of the book (an anchor) and the
+// operations that came after it, in the same records the journal writes.
+// Once a segment has HistorySize operations a new one starts, and only the
+// last two are kept, so at least HistorySize operations are always reachable.
+type historySegment struct {
+	anchor  *Orderbook // the book as of operation start
+	start   int64
+	records []journalRecord // operations start+1, start+2, ...
+}
+
+// The sequence number of the last operation recorded for StateAtSeq, only
+// counted while HistorySize is set
+func (ob *Orderbook) OpSeq() int64 {
+	return ob.opSeq
+}
+
+// Called with each operation before it touches the book
+func (ob *Orderbook) recordHistory(rec journalRecord) {
+	if ob.HistorySize <= 0 || rec.Op == opBook {
+		return
+	}
+
+	if n := len(ob.history); n == 0 || len(ob.history[n-1].records) >= ob.HistorySize {
+		ob.history = append(ob.history, historySegment{anchor: ob.Clone(), start: ob.opSeq})
+		if len(ob.history) > 2 {
+			ob.history = ob.history[1:]
+		}
+	}
+
+	ob.opSeq++
+	last := &ob.history[len(ob.history)-1]
+	last.records = append(last.records, rec)
+}
+
+// Rebuilds the book as it was right after operation seq (see OpSeq), by
+// replaying the retained operations on a copy of the closest earlier anchor.
+// Configuration changed by setting fields directly isn't an operation, the
+// rebuilt book has the configuration of its anchor.
+func (ob *Orderbook) StateAtSeq(seq int64) (*Orderbook, error) {
+	if seq > ob.opSeq {
+		return nil, ErrSeqNotRetained
+	}
+
+	for i := len(ob.history) - 1; i >= 0; i-- {
+		seg := ob.history[i]
+		if seg.start > seq {
+			continue
+		}
+
+		book := seg.anchor.Clone()
+		for _, rec := range seg.records[:seq-seg.start] {
+			if err := book.apply(rec); err != nil {
+				return nil, err
+			}
+		}
+		return book, nil
+	}
+
+	return nil, ErrSeqNotRetained
+}
@@ -0,0 +1,57 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStateAtSeq(t *testing.T) {
+	ob := NewOrderBook()
+	ob.HistorySize = 3
+
+	states := map[int64]BookSnapshot{ob.OpSeq(): ob.Snapshot()}
+	step := func(op func()) {
+		op()
+		states[ob.OpSeq()] = ob.Snapshot()
+	}
+
+	ask := NewOrder(false, 5)
+	step(func() { ob.PlaceLimitOrder(101, ask) })
+	step(func() { ob.PlaceLimitOrder(102, NewOrder(false, 1)) })
+	step(func() { ob.PlaceLimitOrder(99, NewOrder(true, 2)) })
+	step(func() { ob.PlaceMarketOrder(NewOrder(true, 2)) })
+	step(func() { ob.AmendOrder(ask.ID, 101, 1) })
+	step(func() { ob.CancelOrder(ask) })
+	step(func() { ob.PlaceLimitOrder(102, NewOrder(true, 4)) })
+	assert(t, ob.OpSeq(), int64(7))
+
+	// Rejected orders never happened
+	ob.PlaceLimitOrder(-1, NewOrder(true, 1))
+	assert(t, ob.OpSeq(), int64(7))
+
+	for seq := int64(3); seq <= 7; seq++ {
+		past, err := ob.StateAtSeq(seq)
+		assert(t, err, nil)
+		assert(t, past.Snapshot(), states[seq])
+	}
+
+	// Only the last two segments of 3 are kept
+	_, err := ob.StateAtSeq(2)
+	assert(t, errors.Is(err, ErrSeqNotRetained), true)
+	_, err = ob.StateAtSeq(8)
+	assert(t, errors.Is(err, ErrSeqNotRetained), true)
+
+	// Rebuilding doesn't disturb the live book
+	past, _ := ob.StateAtSeq(4)
+	past.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, ob.Snapshot(), states[7])
+}
+
+func TestStateAtSeqOff(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+
+	assert(t, ob.OpSeq(), int64(0))
+	_, err := ob.StateAtSeq(1)
+	assert(t, errors.Is(err, ErrSeqNotRetained), true)
+}
@@ -0,0 +1,307 @@
+package orderbook
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// The integer mode of the book. Prices are whole ticks and sizes are whole
+// lots (think satoshis), so fills add and subtract exactly and volume is never
+// lost to float rounding. It mirrors the core of the float book: price-time
+// priority, limit and market orders, cancels and volume totals.
+
+// Turns a float amount into a whole number of steps, e.g. 100.05 at a 0.01 tick is 10005
+func ToTicks(v, step float64) int64 {
+	return int64(math.Round(v / step))
+}
+
+// Turns a whole number of steps back into a float amount
+func FromTicks(ticks int64, step float64) float64 {
+	return float64(ticks) * step
+}
+
+type IntMatch struct {
+	Ask        *IntOrder
+	Bid        *IntOrder
+	SizeFilled int64
+	Price      int64
+	Timestamp  int64
+}
+
+type IntOrder struct {
+	ID        int64
+	Size      int64 // in lots
+	Bid       bool
+	Limit     *IntLimit
+	Timestamp int64
+}
+
+func NewIntOrder(bid bool, size int64) *IntOrder {
+	return &IntOrder{
+		ID:        nextOrderID(),
+		Size:      size,
+		Bid:       bid,
+		Timestamp: time.Now().UnixNano(),
+	}
+}
+
+func (o *IntOrder) IsFilled() bool {
+	return o.Size == 0
+}
+
+type IntLimit struct {
+	Price       int64 // in ticks
+	Orders      []*IntOrder
+	TotalVolume int64
+
+	book *IntOrderbook
+}
+
+func NewIntLimit(price int64) *IntLimit {
+	return &IntLimit{
+		Price:  price,
+		Orders: []*IntOrder{},
+	}
+}
+
+func (l *IntLimit) AddOrder(o *IntOrder) {
+	o.Limit = l
+	l.Orders = append(l.Orders, o)
+	l.addVolume(o.Bid, o.Size)
+}
+
+// Removes an order from the level, keeping the rest in time priority
+func (l *IntLimit) DeleteOrder(o *IntOrder) {
+	for i := 0; i < len(l.Orders); i++ {
+		if l.Orders[i] == o {
+			l.Orders = append(l.Orders[:i], l.Orders[i+1:]...)
+			break
+		}
+	}
+
+	o.Limit = nil
+	l.addVolume(o.Bid, -o.Size)
+}
+
+func (l *IntLimit) Fill(o *IntOrder) []IntMatch {
+	var (
+		matches        []IntMatch
+		ordersToDelete []*IntOrder
+	)
+
+	for _, order := range l.Orders {
+		match := l.fillOrder(order, o)
+		matches = append(matches, match)
+
+		if order.IsFilled() {
+			ordersToDelete = append(ordersToDelete, order)
+		}
+
+		if o.IsFilled() {
+			break
+		}
+	}
+
+	for _, order := range ordersToDelete {
+		l.DeleteOrder(order)
+
+		if l.book != nil {
+			delete(l.book.Orders, order.ID)
+		}
+	}
+
+	return matches
+}
+
+func (l *IntLimit) fillOrder(a, b *IntOrder) IntMatch {
+	sizeFilled := min(a.Size, b.Size)
+	a.Size -= sizeFilled
+	b.Size -= sizeFilled
+	l.addVolume(a.Bid, -sizeFilled)
+
+	match := IntMatch{
+		SizeFilled: sizeFilled,
+		Price:      l.Price,
+		Timestamp:  time.Now().UnixNano(),
+	}
+	if a.Bid {
+		match.Bid, match.Ask = a, b
+	} else {
+		match.Bid, match.Ask = b, a
+	}
+
+	return match
+}
+
+func (l *IntLimit) addVolume(bid bool, delta int64) {
+	l.TotalVolume += delta
+
+	if l.book == nil {
+		return
+	}
+	if bid {
+		l.book.bidVolume += delta
+	} else {
+		l.book.askVolume += delta
+	}
+}
+
+type IntOrderbook struct {
+	asks []*IntLimit // best (lowest) price first
+	bids []*IntLimit // best (highest) price first
+
+	AskLimits map[int64]*IntLimit
+	BidLimits map[int64]*IntLimit
+	Orders    map[int64]*IntOrder
+
+	askVolume int64
+	bidVolume int64
+}
+
+func NewIntOrderBook() *IntOrderbook {
+	return &IntOrderbook{
+		asks:      []*IntLimit{},
+		bids:      []*IntLimit{},
+		AskLimits: make(map[int64]*IntLimit),
+		BidLimits: make(map[int64]*IntLimit),
+		Orders:    make(map[int64]*IntOrder),
+	}
+}
+
+// Fills as much of the order as the book allows, anything left over is dropped
+func (ob *IntOrderbook) PlaceMarketOrder(o *IntOrder) ([]IntMatch, error) {
+	if o.Size <= 0 {
+		return nil, ErrInvalidSize
+	}
+
+	return ob.match(o, func(int64) bool { return true }), nil
+}
+
+// Matches the order against the other side and rests whatever is left
+func (ob *IntOrderbook) PlaceLimitOrder(price int64, o *IntOrder) ([]IntMatch, error) {
+	if o.Size <= 0 {
+		return nil, ErrInvalidSize
+	}
+	if price <= 0 {
+		return nil, ErrInvalidPrice
+	}
+
+	matches := ob.match(o, func(levelPrice int64) bool {
+		if o.Bid {
+			return price >= levelPrice
+		}
+		return price <= levelPrice
+	})
+
+	if !o.IsFilled() {
+		limit := ob.AskLimits[price]
+		if o.Bid {
+			limit = ob.BidLimits[price]
+		}
+		if limit == nil {
+			limit = NewIntLimit(price)
+			limit.book = ob
+			ob.addLimit(o.Bid, limit)
+		}
+
+		ob.Orders[o.ID] = o
+		limit.AddOrder(o)
+	}
+
+	return matches, nil
+}
+
+func (ob *IntOrderbook) match(o *IntOrder, canFill func(price int64) bool) []IntMatch {
+	matches := []IntMatch{}
+
+	for !o.IsFilled() {
+		side := *ob.levels(!o.Bid)
+		if len(side) == 0 || !canFill(side[0].Price) {
+			break
+		}
+		limit := side[0]
+
+		matches = append(matches, limit.Fill(o)...)
+		if len(limit.Orders) == 0 {
+			ob.clearLimit(!o.Bid, limit)
+		}
+	}
+
+	return matches
+}
+
+func (ob *IntOrderbook) CancelOrder(o *IntOrder) {
+	limit := o.Limit
+	if limit == nil {
+		return
+	}
+
+	limit.DeleteOrder(o)
+	delete(ob.Orders, o.ID)
+
+	if len(limit.Orders) == 0 {
+		ob.clearLimit(o.Bid, limit)
+	}
+}
+
+func (ob *IntOrderbook) levels(bid bool) *[]*IntLimit {
+	if bid {
+		return &ob.bids
+	}
+	return &ob.asks
+}
+
+func (ob *IntOrderbook) levelIndex(bid bool, price int64) int {
+	side := *ob.levels(bid)
+	return sort.Search(len(side), func(i int) bool {
+		if bid {
+			return side[i].Price <= price
+		}
+		return side[i].Price >= price
+	})
+}
+
+func (ob *IntOrderbook) addLimit(bid bool, l *IntLimit) {
+	if bid {
+		ob.BidLimits[l.Price] = l
+	} else {
+		ob.AskLimits[l.Price] = l
+	}
+
+	side := ob.levels(bid)
+	i := ob.levelIndex(bid, l.Price)
+	*side = append(*side, nil)
+	copy((*side)[i+1:], (*side)[i:])
+	(*side)[i] = l
+}
+
+func (ob *IntOrderbook) clearLimit(bid bool, l *IntLimit) {
+	if bid {
+		delete(ob.BidLimits, l.Price)
+	} else {
+		delete(ob.AskLimits, l.Price)
+	}
+
+	side := ob.levels(bid)
+	i := ob.levelIndex(bid, l.Price)
+	if i < len(*side) && (*side)[i] == l {
+		*side = append((*side)[:i], (*side)[i+1:]...)
+	}
+}
+
+func (ob *IntOrderbook) Asks() []*IntLimit {
+	return ob.asks
+}
+
+func (ob *IntOrderbook) Bids() []*IntLimit {
+	return ob.bids
+}
+
+func (ob *IntOrderbook) AskTotalVolume() int64 {
+	return ob.askVolume
+}
+
+func (ob *IntOrderbook) BidTotalVolume() int64 {
+	return ob.bidVolume
+}
@@ -0,0 +1,73 @@
+package orderbook
+
+import "testing"
+
+func TestTicks(t *testing.T) {
+	assert(t, ToTicks(100.05, 0.01), int64(10005))
+	assert(t, ToTicks(0.3, 0.1), int64(3))
+	assert(t, FromTicks(10005, 0.01), 100.05)
+}
+
+func TestIntBookMatching(t *testing.T) {
+	ob := NewIntOrderBook()
+	a, b := NewIntOrder(false, 5), NewIntOrder(false, 3)
+	ob.PlaceLimitOrder(101, a)
+	ob.PlaceLimitOrder(101, b)
+	ob.PlaceLimitOrder(102, NewIntOrder(false, 4))
+
+	matches, err := ob.PlaceLimitOrder(101, NewIntOrder(true, 6))
+	assert(t, err, nil)
+	assert(t, len(matches), 2)
+	assert(t, matches[0].Ask, a)
+	assert(t, matches[1].SizeFilled, int64(1))
+	assert(t, b.Size, int64(2))
+	assert(t, ob.AskTotalVolume(), int64(6))
+
+	matches, _ = ob.PlaceMarketOrder(NewIntOrder(true, 3))
+	assert(t, len(matches), 2)
+	assert(t, matches[1].Price, int64(102))
+	assert(t, len(ob.Asks()), 1)
+
+	bid := NewIntOrder(true, 2)
+	ob.PlaceLimitOrder(100, bid)
+	assert(t, ob.BidTotalVolume(), int64(2))
+	ob.CancelOrder(bid)
+	assert(t, ob.BidTotalVolume(), int64(0))
+	assert(t, len(ob.Bids()), 0)
+	assert(t, len(ob.Orders), 1)
+
+	_, err = ob.PlaceLimitOrder(0, NewIntOrder(true, 1))
+	assert(t, err, ErrInvalidPrice)
+	_, err = ob.PlaceMarketOrder(NewIntOrder(true, 0))
+	assert(t, err, ErrInvalidSize)
+}
+
+// Thousands of 0.001 fills out of one big order. Counted in lots the volume
+// adds up exactly, the same fills in float64 do not.
+func TestIntBookConservesVolume(t *testing.T) {
+	const (
+		lot   = 0.001
+		fills = 5000
+	)
+
+	ob := NewIntOrderBook()
+	ob.PlaceLimitOrder(ToTicks(100, 0.01), NewIntOrder(false, ToTicks(10, lot)))
+
+	filled := int64(0)
+	for i := 0; i < fills; i++ {
+		matches, _ := ob.PlaceMarketOrder(NewIntOrder(true, ToTicks(lot, lot)))
+		filled += matches[0].SizeFilled
+	}
+	assert(t, filled, ToTicks(5, lot))
+	assert(t, ob.AskTotalVolume(), ToTicks(5, lot))
+	assert(t, filled+ob.AskTotalVolume(), ToTicks(10, lot))
+
+	fob := NewOrderBook()
+	fob.PlaceLimitOrder(100, NewOrder(false, 10))
+	ffilled := 0.0
+	for i := 0; i < fills; i++ {
+		matches, _ := fob.PlaceMarketOrder(NewOrder(true, lot))
+		ffilled += matches[0].SizeFilled
+	}
+	assert(t, ffilled == 5 && fob.AskTotalVolume() == 5, false)
+}
@@ -0,0 +1,217 @@
+package orderbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Journal operations. Every record is one JSON line.
+const (
+	opBook     = "book"     // snapshot the journal starts from
+	opLimit    = "limit"    // Orders[0] at Prices[0]
+	opMarket   = "market"   // Orders[0]
+	opStop     = "stop"     // Orders[0]
+	opOCO      = "oco"      // Orders[0] at Prices[0], Orders[1] at Prices[1]
+	opCancel   = "cancel"   // ID
+	opAmend    = "amend"    // ID to Prices[0] and Size
+	opReduce   = "reduce"   // ID by Size
+	opSchedule = "schedule" // Orders[0]
+	opActivate = "activate" // ID
+	opReset    = "reset"
+	opClear    = "clear"
+	opAuction  = "auction"
+	opUncross  = "uncross"
+	opReprice  = "reprice"
+	opReplace  = "replace" // ID by Orders[0] at Prices[0]
+	opMerge    = "merge"   // Orders[i] at Prices[i]
+)
+
+type journalRecord struct {
+	Op       string          `json:"op"`
+	ID       int64           `json:"id,omitempty"`
+	Prices   []float64       `json:"prices,omitempty"`
+	Size     float64         `json:"size,omitempty"`
+	Orders   []OrderSnapshot `json:"orders,omitempty"`
+	Snapshot *BookSnapshot   `json:"snapshot,omitempty"`
+	Time     int64           `json:"time,omitempty"` // book clock when the op was journaled, unix nanos
+}
+
+// Starts appending every operation on the book to w, so the book can be
+// rebuilt with Replay after a crash. The journal opens with a snapshot of the
+// current book (configuration included), and each operation is written after
+// validation but before it touches the book. Pass nil to stop journaling.
+func (ob *Orderbook) SetJournal(w io.Writer) error {
+	ob.journalWriter = w
+	ob.journalErr = nil
+
+	if w == nil {
+		return nil
+	}
+
+	snap := ob.Snapshot()
+	return ob.journal(journalRecord{Op: opBook, Snapshot: &snap})
+}
+
+func (ob *Orderbook) journal(rec journalRecord) error {
+	rec.Time = ob.now().UnixNano()
+	ob.opTime = rec.Time
+
+	if ob.journalWriter != nil && ob.journalErr == nil {
+		data, err := json.Marshal(rec)
+		if err == nil {
+			_, err = ob.journalWriter.Write(append(data, '\n'))
+		}
+		if err != nil {
+			ob.journalErr = fmt.Errorf("journal: %w", err)
+		}
+	}
+
+	// Placements are refused once the journal fails, cancels and resets go ahead anyway
+	if ob.journalErr == nil || rec.Op == opCancel || rec.Op == opReset || rec.Op == opClear {
+		ob.recordHistory(rec)
+	}
+
+	return ob.journalErr
+}
+
+// Journals orders as they are before they reach the book, since matching
+// changes their size
+func (ob *Orderbook) journalOrders(op string, orders []*Order, prices ...float64) error {
+	if ob.journalWriter == nil && ob.HistorySize <= 0 {
+		ob.opTime = ob.now().UnixNano() // nothing to write, but the op still needs its time
+		return ob.journalErr
+	}
+
+	rec := journalRecord{Op: op, Prices: prices}
+	for _, o := range orders {
+		rec.Orders = append(rec.Orders, snapshotOrder(o))
+	}
+
+	return ob.journal(rec)
+}
+
+// Rebuilds a book by re-applying a journal written through SetJournal
+func Replay(r io.Reader) (*Orderbook, error) {
+	ob := NewOrderBook()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024) // book snapshots can be large
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("journal line %d: %w", lineNum, err)
+		}
+		if err := ob.apply(rec); err != nil {
+			return nil, fmt.Errorf("journal line %d: %w", lineNum, err)
+		}
+	}
+
+	return ob, scanner.Err()
+}
+
+func (ob *Orderbook) apply(rec journalRecord) error {
+	var err error
+
+	// Replays on the clock the op ran on, so orders it re-queues and trades it
+	// makes get the same timestamps they got the first time
+	if rec.Time != 0 {
+		now := ob.now
+		ob.now = func() time.Time { return time.Unix(0, rec.Time) }
+		defer func() { ob.now = now }()
+		ob.opTime = rec.Time // cancels and activations aren't journaled again
+	}
+
+	switch rec.Op {
+	case opBook:
+		if rec.Snapshot == nil {
+			return fmt.Errorf("book record without a snapshot")
+		}
+		ob.restore(*rec.Snapshot)
+	case opLimit:
+		_, err = ob.PlaceLimitOrder(rec.Prices[0], rec.Orders[0].order())
+	case opMarket:
+		_, err = ob.PlaceMarketOrder(rec.Orders[0].order())
+	case opStop:
+		_, err = ob.PlaceStopOrder(rec.Orders[0].order())
+	case opOCO:
+		_, err = ob.PlaceOCO(rec.Orders[0].order(), rec.Orders[1].order(), rec.Prices[0], rec.Prices[1])
+	case opCancel:
+		o := ob.findOrder(rec.ID)
+		if o == nil {
+			return ErrOrderNotFound
+		}
+		ob.cancel(o)
+	case opAmend:
+		err = ob.AmendOrder(rec.ID, rec.Prices[0], rec.Size)
+	case opReplace:
+		o := ob.findOrder(rec.ID)
+		if o == nil {
+			return ErrOrderNotFound
+		}
+		ob.replace(o, rec.Prices[0], rec.Orders[0].order())
+	case opMerge:
+		orders := make([]*Order, len(rec.Orders))
+		for i, snap := range rec.Orders {
+			orders[i] = snap.order()
+		}
+		ob.merge(orders, rec.Prices)
+	case opReduce:
+		err = ob.ReduceOrder(rec.ID, rec.Size)
+	case opSchedule:
+		err = ob.PlaceScheduledOrder(rec.Orders[0].order())
+	case opActivate:
+		o := ob.findOrder(rec.ID)
+		if o == nil {
+			return ErrOrderNotFound
+		}
+		ob.activate(o)
+	case opReprice:
+		ob.Reprice()
+	case opReset:
+		ob.Reset()
+	case opClear:
+		ob.Clear()
+	case opAuction:
+		ob.StartAuction()
+	case opUncross:
+		_, _, err = ob.Uncross()
+		if errors.Is(err, ErrNoCross) {
+			err = nil // ended the auction all the same
+		}
+	default:
+		return fmt.Errorf("unknown journal op %q", rec.Op)
+	}
+
+	return err
+}
+
+// The timestamp for orders re-queued and trades made by the operation in
+// progress: the time it was journaled at, so replaying the journal stamps them
+// the same way
+func (ob *Orderbook) stamp() int64 {
+	if ob.opTime != 0 {
+		return ob.opTime
+	}
+	return ob.now().UnixNano()
+}
+
+// Looks an order up among resting orders and pending stops
+func (ob *Orderbook) findOrder(id int64) *Order {
+	if o, ok := ob.Orders[id]; ok {
+		return o
+	}
+	for _, pending := range append(ob.stops, ob.scheduled...) {
+		if pending.ID == id {
+			return pending
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package orderbook
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalReplay(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TickSize = 0.5
+	ob.PlaceLimitOrder(99, NewOrder(true, 3)) // before journaling, comes in through the snapshot
+
+	var journal bytes.Buffer
+	assert(t, ob.SetJournal(&journal), nil)
+
+	sell := NewOrder(false, 5, WithTraderID("alice"))
+	amended := NewOrder(true, 4)
+	ob.PlaceLimitOrder(101, sell)
+	ob.PlaceLimitOrder(100, amended)
+	ob.PlaceLimitOrder(102, NewOrder(false, 2))
+	ob.PlaceStopOrder(NewOrder(true, 1, WithStopPrice(101)))
+	ob.PlaceMarketOrder(NewOrder(true, 2)) // trades at 101 and fires the stop
+	ob.AmendOrder(amended.ID, 100.5, 2)
+	cancelled := NewOrder(false, 1)
+	ob.PlaceLimitOrder(103, cancelled)
+	ob.CancelOrder(cancelled)
+
+	replayed, err := Replay(&journal)
+	assert(t, err, nil)
+
+	assertSameBook(t, ob, replayed)
+	assert(t, replayed.TickSize, 0.5)
+	assert(t, replayed.ToSpec(), "S 2 @ 102\nS 2 @ 101\nB 2 @ 100.5\nB 3 @ 99\n")
+	assert(t, replayed.OpenOrders("alice")[0].ID, sell.ID)
+}
+
+func TestReplayKeepsRequeuedOrdersInPlace(t *testing.T) {
+	clock := time.Unix(0, 1000)
+	ob := NewOrderBook()
+	ob.HistorySize = 100
+	ob.SetClock(func() time.Time { return clock })
+	stamped := func(o *Order, ts int64) *Order {
+		o.Timestamp = ts
+		return o
+	}
+
+	var journal bytes.Buffer
+	ob.SetJournal(&journal)
+
+	amended := stamped(NewOrder(false, 1), 1000)
+	ob.PlaceLimitOrder(102, amended)
+	clock = time.Unix(0, 2000)
+	ob.AmendOrder(amended.ID, 101, 1) // to the back of 101 as of 2000
+	later := stamped(NewOrder(false, 1), 3000)
+	ob.PlaceLimitOrder(101, later)
+
+	iceberg := stamped(NewOrder(false, 2, WithDisplaySize(1)), 1000)
+	ob.PlaceLimitOrder(100, iceberg)
+	clock = time.Unix(0, 4000)
+	ob.PlaceMarketOrder(NewOrder(true, 1)) // new peak as of 4000
+	behindPeak := stamped(NewOrder(false, 1), 5000)
+	ob.PlaceLimitOrder(100, behindPeak)
+
+	replayed, err := Replay(&journal)
+	assert(t, err, nil)
+	rebuilt, err := ob.StateAtSeq(ob.OpSeq())
+	assert(t, err, nil)
+
+	for _, book := range []*Orderbook{ob, replayed, rebuilt} {
+		assertSameBook(t, ob, book)
+		asks := book.Asks()
+		assert(t, asks[0].Orders[0].ID, iceberg.ID)
+		assert(t, asks[0].Orders[1].ID, behindPeak.ID)
+		assert(t, asks[1].Orders[0].ID, amended.ID)
+		assert(t, asks[1].Orders[1].ID, later.ID)
+	}
+}
+
+func TestJournalRejectedOrdersAreNotWritten(t *testing.T) {
+	ob := NewOrderBook()
+
+	var journal bytes.Buffer
+	ob.SetJournal(&journal)
+	ob.PlaceLimitOrder(-1, NewOrder(true, 1))
+
+	assert(t, strings.Count(journal.String(), "\n"), 1) // just the opening snapshot
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestJournalWriteFailure(t *testing.T) {
+	ob := NewOrderBook()
+	err := ob.SetJournal(failingWriter{})
+	assert(t, err != nil, true)
+
+	// Nothing goes on the book if it can't be journaled first
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, err != nil, true)
+	assert(t, len(ob.Orders), 0)
+
+	ob.SetJournal(nil)
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, err, nil)
+}
+
+func TestReplayBadJournal(t *testing.T) {
+	_, err := Replay(strings.NewReader(`{"op":"cancel","id":42}`))
+	assert(t, errors.Is(err, ErrOrderNotFound), true)
+
+	_, err = Replay(strings.NewReader("not json"))
+	assert(t, err != nil, true)
+}
@@ -0,0 +1,58 @@
+package orderbook
+
+import "errors"
+
+var ErrBookFull = errors.New("side of the book already has the maximum number of price levels")
+
+// Whether placing o at price would need a new level on a side that already
+// has MaxLevelsPerSide of them. Orders joining an existing level, that fill
+// completely or that are IOC and never rest, are fine. With EvictWorstLevel
+// a price better than the worst level is fine too, placing it evicts that
+// level instead.
+func (ob *Orderbook) checkLevelCap(price float64, o *Order) error {
+	if !ob.atLevelCap(o.Bid) || o.TimeInForce == IOC {
+		return nil
+	}
+	if o.Limit != nil && o.Limit.Price != price && len(o.Limit.Orders) == 1 {
+		return nil // moving the only order off its level frees that level up
+	}
+	limits := ob.AskLimits
+	if o.Bid {
+		limits = ob.BidLimits
+	}
+	if _, ok := limits[price]; ok {
+		return nil
+	}
+	if ob.fillableVolume(o, price) >= o.Size {
+		return nil
+	}
+	if ob.EvictWorstLevel && better(o.Bid, price, ob.worst(o.Bid).Price) {
+		return nil
+	}
+	return ErrBookFull
+}
+
+func (ob *Orderbook) atLevelCap(bid bool) bool {
+	return ob.MaxLevelsPerSide > 0 && ob.levels(bid).Len() >= ob.MaxLevelsPerSide
+}
+
+// Makes room for a new level at price by cancelling every order at the worst
+// level on that side, if the side is full and eviction is on
+func (ob *Orderbook) makeRoom(bid bool, price float64) {
+	if !ob.EvictWorstLevel || !ob.atLevelCap(bid) {
+		return
+	}
+
+	worst := ob.worst(bid)
+	if !better(bid, price, worst.Price) {
+		return
+	}
+
+	ob.debug("level evicted", "bid", bid, "price", worst.Price)
+	orders := make([]*Order, len(worst.Orders))
+	copy(orders, worst.Orders)
+	for _, o := range orders {
+		ob.cancelOrder(o)
+		o.Status = StatusCancelled
+	}
+}
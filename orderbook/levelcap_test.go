@@ -0,0 +1,65 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMaxLevelsPerSideRejects(t *testing.T) {
+	ob := NewOrderBook()
+	ob.MaxLevelsPerSide = 2
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+
+	_, err := ob.PlaceLimitOrder(102, NewOrder(false, 1))
+	assert(t, errors.Is(err, ErrBookFull), true)
+	_, err = ob.PlaceLimitOrder(99, NewOrder(false, 1))
+	assert(t, errors.Is(err, ErrBookFull), true)
+	assert(t, len(ob.Asks()), 2)
+
+	// Existing levels still take orders, and the other side has its own cap
+	_, err = ob.PlaceLimitOrder(101, NewOrder(false, 2))
+	assert(t, err, nil)
+	assert(t, ob.VolumeAtPrice(101), 3.0)
+	_, err = ob.PlaceLimitOrder(98, NewOrder(true, 1))
+	assert(t, err, nil)
+
+	// A new price that fills completely never opens a level
+	ob.MaxLevelsPerSide = 1
+	_, err = ob.PlaceLimitOrder(97, NewOrder(true, 1))
+	assert(t, errors.Is(err, ErrBookFull), true)
+	matches, err := ob.PlaceLimitOrder(101, NewOrder(true, 2))
+	assert(t, err, nil)
+	assert(t, len(matches), 2)
+
+	// Once a level clears there's room again
+	ob.CancelOrder(ob.BestBid().Orders[0])
+	_, err = ob.PlaceLimitOrder(97, NewOrder(true, 1))
+	assert(t, err, nil)
+}
+
+func TestMaxLevelsPerSideEvicts(t *testing.T) {
+	ob := NewOrderBook()
+	ob.MaxLevelsPerSide = 2
+	ob.EvictWorstLevel = true
+
+	worst := NewOrder(true, 1)
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	ob.PlaceLimitOrder(99, worst)
+	ob.PlaceLimitOrder(99, NewOrder(true, 2))
+
+	// Worse than every level we have, nothing to evict for it
+	_, err := ob.PlaceLimitOrder(98, NewOrder(true, 1))
+	assert(t, errors.Is(err, ErrBookFull), true)
+
+	_, err = ob.PlaceLimitOrder(101, NewOrder(true, 1))
+	assert(t, err, nil)
+	assert(t, len(ob.Bids()), 2)
+	assert(t, ob.Bids()[0].Price, 101.0)
+	assert(t, ob.Bids()[1].Price, 100.0)
+	assert(t, ob.BidTotalVolume(), 2.0)
+	assert(t, worst.Status, StatusCancelled)
+	assert(t, worst.Limit == nil, true)
+	assert(t, ob.NumOrders(), 2)
+}
@@ -0,0 +1,75 @@
+package orderbook
+
+// How a price level's volume moved, NewVolume 0 means the level is gone and
+// OldVolume 0 that it's new
+type LevelChange struct {
+	Bid       bool
+	Price     float64
+	OldVolume float64
+	NewVolume float64
+}
+
+type levelKey struct {
+	bid   bool
+	price float64
+}
+
+// Starts collecting level changes for LevelChanges. Until then, and after
+// StopLevelChanges, the book doesn't keep them.
+func (ob *Orderbook) TrackLevelChanges() {
+	if ob.levelChangeAt == nil {
+		ob.levelChangeAt = make(map[levelKey]int)
+	}
+}
+
+// Stops collecting level changes and drops the ones not read yet
+func (ob *Orderbook) StopLevelChanges() {
+	ob.levelChanges = nil
+	ob.levelChangeAt = nil
+}
+
+// The levels whose volume changed since the previous call, in the order they
+// were first changed, and starts a new change set. Call it after each
+// PlaceLimitOrder, PlaceMarketOrder, CancelOrder and so on to get just the
+// rows that operation changed, e.g. to repaint only those in a UI. Returns
+// nothing unless TrackLevelChanges was called.
+func (ob *Orderbook) LevelChanges() []LevelChange {
+	var changes []LevelChange
+	for _, c := range ob.levelChanges {
+		if c.OldVolume != c.NewVolume {
+			changes = append(changes, c)
+		}
+	}
+	ob.clearLevelChanges()
+
+	return changes
+}
+
+func (ob *Orderbook) clearLevelChanges() {
+	ob.levelChanges = ob.levelChanges[:0]
+	for key := range ob.levelChangeAt {
+		delete(ob.levelChangeAt, key)
+	}
+}
+
+// Folds a flushed level update into the change set, a level changed several
+// times keeps the volume it had before the first change
+func (ob *Orderbook) recordLevelChange(oldVolume float64, update LevelUpdate) {
+	if ob.levelChangeAt == nil {
+		return
+	}
+
+	key := levelKey{bid: update.Bid, price: update.Price}
+	if i, ok := ob.levelChangeAt[key]; ok {
+		ob.levelChanges[i].NewVolume = update.NewVolume
+		return
+	}
+
+	ob.levelChangeAt[key] = len(ob.levelChanges)
+	ob.levelChanges = append(ob.levelChanges, LevelChange{
+		Bid:       update.Bid,
+		Price:     update.Price,
+		OldVolume: oldVolume,
+		NewVolume: update.NewVolume,
+	})
+}
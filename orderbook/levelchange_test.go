@@ -0,0 +1,79 @@
+package orderbook
+
+import "testing"
+
+func TestLevelChanges(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TrackLevelChanges()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	ob.PlaceLimitOrder(101, NewOrder(false, 3))
+	ob.PlaceLimitOrder(102, NewOrder(false, 4))
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	ob.LevelChanges()
+
+	// sweeps 100 and part of 101, 102 and the bids aren't touched
+	ob.PlaceMarketOrder(NewOrder(true, 4))
+	assert(t, ob.LevelChanges(), []LevelChange{
+		{Bid: false, Price: 100, OldVolume: 2, NewVolume: 0},
+		{Bid: false, Price: 101, OldVolume: 3, NewVolume: 1},
+	})
+
+	// takes the rest of 101 and rests the remainder at a new level
+	ob.PlaceLimitOrder(101, NewOrder(true, 3))
+	assert(t, ob.LevelChanges(), []LevelChange{
+		{Bid: false, Price: 101, OldVolume: 1, NewVolume: 0},
+		{Bid: true, Price: 101, OldVolume: 0, NewVolume: 2},
+	})
+
+	assert(t, len(ob.LevelChanges()), 0)
+}
+
+func TestLevelChangesFoldRepeatedTouches(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TrackLevelChanges()
+	a := NewOrder(true, 2)
+	b := NewOrder(true, 3)
+	ob.PlaceLimitOrder(99, a)
+	ob.PlaceLimitOrder(99, b)
+	ob.CancelOrder(a)
+
+	assert(t, ob.LevelChanges(), []LevelChange{
+		{Bid: true, Price: 99, OldVolume: 0, NewVolume: 3},
+	})
+
+	// a level that ends up where it started didn't change
+	c := NewOrder(true, 1)
+	ob.PlaceLimitOrder(99, c)
+	ob.CancelOrder(c)
+	assert(t, len(ob.LevelChanges()), 0)
+}
+
+func TestLevelChangesOnlyWhileTracked(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	assert(t, len(ob.levelChanges), 0)
+
+	ob.TrackLevelChanges()
+	ob.PlaceLimitOrder(98, NewOrder(true, 1))
+	ob.StopLevelChanges()
+	ob.PlaceLimitOrder(97, NewOrder(true, 1))
+	assert(t, len(ob.LevelChanges()), 0)
+}
+
+func TestResetClearsLevelChanges(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TrackLevelChanges()
+	ob.PlaceLimitOrder(99, NewOrder(true, 2))
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.LevelChanges()
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	ob.Reset()
+
+	// only what the reset did, the add at 100 before it is dropped
+	assert(t, ob.LevelChanges(), []LevelChange{
+		{Bid: false, Price: 101, OldVolume: 1, NewVolume: 0},
+		{Bid: true, Price: 100, OldVolume: 1, NewVolume: 0},
+		{Bid: true, Price: 99, OldVolume: 2, NewVolume: 0},
+	})
+}
@@ -0,0 +1,163 @@
+package orderbook
+
+import "sort"
+
+// One side of the book's price levels, best price first: asks from the lowest
+// price up, bids from the highest price down. The book only ever goes through
+// these methods, so the data structure behind a side can be swapped out, see
+// NewOrderBookWithLevelStore.
+type LevelStore interface {
+	BestLimit() *Limit              // nil when the side is empty
+	Insert(l *Limit)                // adds a level, the side has none at its price yet
+	Remove(l *Limit)                // takes the level out, does nothing if it isn't there
+	Get(price float64) *Limit       // nil when there's no level at price
+	Iterate(fn func(l *Limit) bool) // best price first, stops as soon as fn returns false
+	Len() int
+}
+
+// The default LevelStore, a slice kept sorted best price first. New levels are
+// inserted in place and removed ones are cut out without reordering the rest,
+// so the best level is always at index 0 and nothing ever has to sort.
+type sliceLevels struct {
+	bid    bool
+	levels []*Limit
+}
+
+func NewSliceLevelStore(bid bool) LevelStore {
+	return &sliceLevels{bid: bid, levels: []*Limit{}}
+}
+
+// Where a level at price sits, or would sit
+func (s *sliceLevels) index(price float64) int {
+	return sort.Search(len(s.levels), func(i int) bool {
+		return !better(s.bid, s.levels[i].Price, price)
+	})
+}
+
+func (s *sliceLevels) BestLimit() *Limit {
+	if len(s.levels) == 0 {
+		return nil
+	}
+	return s.levels[0]
+}
+
+func (s *sliceLevels) Insert(l *Limit) {
+	i := s.index(l.Price)
+	s.levels = append(s.levels, nil)
+	copy(s.levels[i+1:], s.levels[i:])
+	s.levels[i] = l
+}
+
+func (s *sliceLevels) Remove(l *Limit) {
+	i := s.index(l.Price)
+	if i == len(s.levels) || s.levels[i] != l {
+		return
+	}
+
+	copy(s.levels[i:], s.levels[i+1:])
+	s.levels[len(s.levels)-1] = nil
+	s.levels = s.levels[:len(s.levels)-1]
+}
+
+func (s *sliceLevels) Get(price float64) *Limit {
+	i := s.index(price)
+	if i == len(s.levels) || s.levels[i].Price != price {
+		return nil
+	}
+	return s.levels[i]
+}
+
+func (s *sliceLevels) Iterate(fn func(l *Limit) bool) {
+	for _, l := range s.levels {
+		if !fn(l) {
+			return
+		}
+	}
+}
+
+func (s *sliceLevels) Len() int {
+	return len(s.levels)
+}
+
+// Whether price a is a better price than b for that side of the book
+func better(bid bool, a, b float64) bool {
+	if bid {
+		return a > b
+	}
+	return a < b
+}
+
+// The store for one side, made on first use so a zero value book works too
+func (ob *Orderbook) levels(bid bool) LevelStore {
+	side := &ob.asks
+	if bid {
+		side = &ob.bids
+	}
+
+	if *side == nil {
+		newStore := ob.newLevelStore
+		if newStore == nil {
+			newStore = defaultLevelStore
+		}
+		*side = newStore(bid)
+	}
+	return *side
+}
+
+// Every level on one side, best price first
+func (ob *Orderbook) limits(bid bool) []*Limit {
+	limits := make([]*Limit, 0, ob.levels(bid).Len())
+	ob.levels(bid).Iterate(func(l *Limit) bool {
+		limits = append(limits, l)
+		return true
+	})
+	return limits
+}
+
+// The best level on one side of the book, nil when the side is empty
+func (ob *Orderbook) best(bid bool) *Limit {
+	return ob.levels(bid).BestLimit()
+}
+
+// The level furthest from the top of the book, nil when the side is empty
+func (ob *Orderbook) worst(bid bool) *Limit {
+	var worst *Limit
+	ob.levels(bid).Iterate(func(l *Limit) bool {
+		worst = l
+		return true
+	})
+	return worst
+}
+
+// The first level priced worse than after, or the best level when after is
+// nil. Still works once after itself has been removed.
+func (ob *Orderbook) nextLevel(bid bool, after *Limit) *Limit {
+	if after == nil {
+		return ob.best(bid)
+	}
+
+	var next *Limit
+	ob.levels(bid).Iterate(func(l *Limit) bool {
+		if better(bid, after.Price, l.Price) {
+			next = l
+			return false
+		}
+		return true
+	})
+	return next
+}
+
+// Adds a new level to its side of the book
+func (ob *Orderbook) addLimit(bid bool, l *Limit) {
+	if bid {
+		ob.BidLimits[l.Price] = l
+	} else {
+		ob.AskLimits[l.Price] = l
+	}
+	ob.levels(bid).Insert(l)
+}
+
+// Takes a level out of its side of the book, keeping the others in order
+func (ob *Orderbook) removeLimit(bid bool, l *Limit) {
+	ob.levels(bid).Remove(l)
+}
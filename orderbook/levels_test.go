@@ -0,0 +1,307 @@
+package orderbook
+
+import (
+	"container/list"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+)
+
+// Runs the whole suite once per LevelStore, to prove they behave the same
+func TestMain(m *testing.M) {
+	for _, store := range []struct {
+		name     string
+		newStore func(bid bool) LevelStore
+	}{
+		{"slice", NewSliceLevelStore},
+		{"list", newListLevels},
+		{"heap", NewHeapLevelStore},
+	} {
+		defaultLevelStore = store.newStore
+		if code := m.Run(); code != 0 {
+			fmt.Printf("failed with the %s level store\n", store.name)
+			os.Exit(code)
+		}
+	}
+}
+
+// A linked list LevelStore, as different from the slice as it gets while
+// still keeping the levels in order
+type listLevels struct {
+	bid    bool
+	levels *list.List
+}
+
+func newListLevels(bid bool) LevelStore {
+	return &listLevels{bid: bid, levels: list.New()}
+}
+
+func (s *listLevels) BestLimit() *Limit {
+	if s.levels.Len() == 0 {
+		return nil
+	}
+	return s.levels.Front().Value.(*Limit)
+}
+
+func (s *listLevels) Insert(l *Limit) {
+	for e := s.levels.Front(); e != nil; e = e.Next() {
+		if better(s.bid, l.Price, e.Value.(*Limit).Price) {
+			s.levels.InsertBefore(l, e)
+			return
+		}
+	}
+	s.levels.PushBack(l)
+}
+
+func (s *listLevels) Remove(l *Limit) {
+	for e := s.levels.Front(); e != nil; e = e.Next() {
+		if e.Value == l {
+			s.levels.Remove(e)
+			return
+		}
+	}
+}
+
+func (s *listLevels) Get(price float64) *Limit {
+	for e := s.levels.Front(); e != nil; e = e.Next() {
+		if l := e.Value.(*Limit); l.Price == price {
+			return l
+		}
+	}
+	return nil
+}
+
+func (s *listLevels) Iterate(fn func(l *Limit) bool) {
+	for e := s.levels.Front(); e != nil; e = e.Next() {
+		if !fn(e.Value.(*Limit)) {
+			return
+		}
+	}
+}
+
+func (s *listLevels) Len() int {
+	return s.levels.Len()
+}
+
+func TestLevelStores(t *testing.T) {
+	for _, newStore := range []func(bool) LevelStore{NewSliceLevelStore, newListLevels} {
+		asks := newStore(false)
+		assert(t, asks.BestLimit() == nil, true)
+
+		limits := map[float64]*Limit{}
+		for _, price := range []float64{103, 101, 104, 102} {
+			limits[price] = NewLimit(price)
+			asks.Insert(limits[price])
+		}
+		asks.Remove(limits[102])
+		asks.Remove(NewLimit(101)) // not the stored level
+
+		var prices []float64
+		asks.Iterate(func(l *Limit) bool {
+			prices = append(prices, l.Price)
+			return l.Price < 103
+		})
+		assert(t, prices, []float64{101, 103})
+		assert(t, asks.Len(), 3)
+		assert(t, asks.BestLimit(), limits[101])
+		assert(t, asks.Get(104), limits[104])
+		assert(t, asks.Get(102) == nil, true)
+
+		bids := newStore(true)
+		bids.Insert(NewLimit(99))
+		bids.Insert(NewLimit(100))
+		assert(t, bids.BestLimit().Price, 100.0)
+	}
+}
+
+func TestNewOrderBookWithLevelStore(t *testing.T) {
+	ob := NewOrderBookWithLevelStore(newListLevels)
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	_, ok := ob.asks.(*listLevels)
+	assert(t, ok, true)
+	assert(t, ob.BestAsk().Price, 100.0)
+
+	// clones and a reset keep the kind of store
+	_, ok = ob.Clone().asks.(*listLevels)
+	assert(t, ok, true)
+	ob.Reset()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	_, ok = ob.asks.(*listLevels)
+	assert(t, ok, true)
+}
+
+func TestBestLevelAfterRemovals(t *testing.T) {
+	ob := NewOrderBook()
+	for _, price := range []float64{105, 101, 103, 102, 104} {
+		ob.PlaceLimitOrder(price, NewOrder(false, 1))
+		ob.PlaceLimitOrder(price-10, NewOrder(true, 1))
+	}
+	assert(t, ob.BestAsk().Price, 101.0)
+	assert(t, ob.BestBid().Price, 95.0)
+
+	ob.CancelOrder(ob.AskLimits[101].Orders[0])
+	ob.CancelOrder(ob.BidLimits[95].Orders[0])
+	assert(t, ob.BestAsk().Price, 102.0)
+	assert(t, ob.BestBid().Price, 94.0)
+
+	// eat through two ask levels in one go
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, ob.BestAsk().Price, 104.0)
+	assert(t, len(ob.Asks()), 2)
+
+	asks := ob.Asks()
+	assert(t, asks[0].Price, 104.0)
+	assert(t, asks[1].Price, 105.0)
+}
+
+func TestLevelsStaySorted(t *testing.T) {
+	ob := NewOrderBook()
+	rng := rand.New(rand.NewSource(7))
+	for _, i := range rng.Perm(200) {
+		ob.PlaceLimitOrder(float64(1000+i), NewOrder(false, 1))
+		ob.PlaceLimitOrder(float64(999-i), NewOrder(true, 1))
+	}
+
+	// clear a few levels from the middle and the ends
+	for _, price := range []float64{1000, 1100, 1199, 1050} {
+		ob.CancelOrder(ob.AskLimits[price].Orders[0])
+	}
+	for _, price := range []float64{999, 900, 800, 850} {
+		ob.CancelOrder(ob.BidLimits[price].Orders[0])
+	}
+
+	asks, bids := ob.Asks(), ob.Bids()
+	assert(t, len(asks), 196)
+	assert(t, len(bids), 196)
+	for i := 1; i < len(asks); i++ {
+		assert(t, asks[i-1].Price < asks[i].Price, true)
+	}
+	for i := 1; i < len(bids); i++ {
+		assert(t, bids[i-1].Price > bids[i].Price, true)
+	}
+	assert(t, asks[0].Price, 1001.0)
+	assert(t, bids[0].Price, 998.0)
+}
+
+func TestClearLimitKeepsOrder(t *testing.T) {
+	ob := NewOrderBook()
+	for _, price := range []float64{103, 101, 104, 102} {
+		ob.PlaceLimitOrder(price, NewOrder(false, 1))
+		ob.PlaceLimitOrder(price-10, NewOrder(true, 1))
+	}
+
+	ob.clearLimit(false, ob.AskLimits[102])
+	ob.clearLimit(true, ob.BidLimits[92])
+
+	// straight off the slices, nothing gets sorted
+	prices := func(limits []*Limit) []float64 {
+		ps := []float64{}
+		for _, l := range limits {
+			ps = append(ps, l.Price)
+		}
+		return ps
+	}
+	assert(t, prices(ob.Asks()), []float64{101, 103, 104})
+	assert(t, prices(ob.Bids()), []float64{94, 93, 91})
+	assert(t, ob.AskTotalVolume(), 3.0)
+	assert(t, ob.BidTotalVolume(), 3.0)
+	_, ok := ob.AskLimits[102]
+	assert(t, ok, false)
+}
+
+func TestSkippedLevelsStayOnTheBook(t *testing.T) {
+	ob := NewOrderBook()
+	ob.STP = STPSkip
+	ob.PlaceLimitOrder(100, NewOrder(false, 1, WithTraderID("alice")))
+	ob.PlaceLimitOrder(101, NewOrder(false, 1, WithTraderID("bob")))
+
+	// alice's own ask at 100 is skipped, she trades with bob at 101
+	matches, _ := ob.PlaceLimitOrder(101, NewOrder(true, 1, WithTraderID("alice")))
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Price, 101.0)
+	assert(t, ob.BestAsk().Price, 100.0)
+	assert(t, len(ob.Asks()), 1)
+}
+
+const benchLevels = 10_000
+
+func benchPrices() []float64 {
+	prices := make([]float64, benchLevels)
+	for i := range prices {
+		prices[i] = float64((i*7919)%benchLevels + 1) // a scrambled 1..10k
+	}
+	return prices
+}
+
+// The old approach: append to an unsorted slice and sort it to find the best level
+func BenchmarkBestAskSort(b *testing.B) {
+	limits := Limits{}
+	for _, price := range benchPrices() {
+		limits = append(limits, NewLimit(price))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		limits = append(limits, NewLimit(0.5))
+		sort.Sort(ByBestAsk{limits})
+		_ = limits[0]
+		limits = limits[1:]
+	}
+}
+
+func BenchmarkBestAskSortedInsert(b *testing.B) {
+	ob := NewOrderBook()
+	for _, price := range benchPrices() {
+		ob.addLimit(false, NewLimit(price))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := NewLimit(0.5)
+		ob.addLimit(false, l)
+		_ = ob.BestAsk()
+		ob.removeLimit(false, l)
+		delete(ob.AskLimits, l.Price)
+	}
+}
+
+// Adding and dropping a level deep in a 10k level book, and the same followed
+// by a walk over the best few levels the way matching does
+func BenchmarkLevelStores(b *testing.B) {
+	for _, store := range []struct {
+		name     string
+		newStore func(bid bool) LevelStore
+	}{
+		{"slice", NewSliceLevelStore},
+		{"heap", NewHeapLevelStore},
+	} {
+		side := store.newStore(false)
+		for _, price := range benchPrices() {
+			side.Insert(NewLimit(price))
+		}
+
+		b.Run(store.name+"/insert", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l := NewLimit(0.5)
+				side.Insert(l)
+				_ = side.BestLimit()
+				side.Remove(l)
+			}
+		})
+		b.Run(store.name+"/insert-and-walk", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l := NewLimit(0.5)
+				side.Insert(l)
+				walked := 0
+				side.Iterate(func(*Limit) bool {
+					walked++
+					return walked < 5
+				})
+				side.Remove(l)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package orderbook
+
+import "log/slog"
+
+// Logs what the matching engine does (orders placed, matched and cancelled,
+// limits cleared) at debug level, and recovered panics at error level. Pass
+// nil to go back to logging nothing.
+func (ob *Orderbook) SetLogger(logger *slog.Logger) {
+	ob.logger = logger
+}
+
+func (ob *Orderbook) debug(msg string, args ...any) {
+	if ob.logger != nil {
+		ob.logger.Debug(msg, args...)
+	}
+}
+
+func (ob *Orderbook) logError(msg string, args ...any) {
+	if ob.logger != nil {
+		ob.logger.Error(msg, args...)
+	}
+}
@@ -0,0 +1,49 @@
+package orderbook
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+// Keeps the message of every record it gets
+type captureHandler struct {
+	msgs *[]string
+}
+
+func (h captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h captureHandler) Handle(_ context.Context, r slog.Record) error {
+	*h.msgs = append(*h.msgs, r.Message)
+	return nil
+}
+
+func TestLoggerEvents(t *testing.T) {
+	var msgs []string
+	ob := NewOrderBook()
+	ob.SetLogger(slog.New(captureHandler{&msgs}))
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	ob.PlaceLimitOrder(100, NewOrder(true, 2))
+	resting := NewOrder(true, 1)
+	ob.PlaceLimitOrder(99, resting)
+	ob.CancelOrder(resting)
+
+	assert(t, msgs, []string{
+		"order placed",
+		"order placed",
+		"order matched",
+		"limit cleared",
+		"order placed",
+		"order cancelled",
+		"limit cleared",
+	})
+
+	// No logger, no output and nothing breaks
+	msgs = nil
+	ob.SetLogger(nil)
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	assert(t, len(msgs), 0)
+}
@@ -0,0 +1,82 @@
+package orderbook
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrDuplicateOrderID = errors.New("order id is already in the book")
+
+// Brings other's resting orders into this book, keeping their ids. They go in
+// oldest first, so they keep their time priority among each other, and any
+// that cross this book's other side trade like a new order would (see Trades
+// for what they did). Copies are moved over, other is left as it was, and its
+// pending stops and scheduled orders stay behind. It's all or nothing: every
+// order is checked like a new placement (halt, band, level cap, accounts) and
+// the whole merge is journaled before the first one goes in, so an id that is
+// in both books, a rejected order or a failed journal write changes nothing.
+func (ob *Orderbook) Merge(other *Orderbook) error {
+	orders := other.AllOrders()
+	for _, o := range orders {
+		if ob.findOrder(o.ID) != nil {
+			return ErrDuplicateOrderID
+		}
+	}
+	sort.Stable(Orders(orders))
+
+	copies := make([]*Order, len(orders))
+	prices := make([]float64, len(orders))
+	for i, o := range orders {
+		copies[i], prices[i] = snapshotOrder(o).order(), o.Limit.Price
+		if err := ob.admit(copies[i], prices[i], true); err != nil {
+			return err
+		}
+		if err := ob.validateLimit(prices[i], copies[i]); err != nil {
+			return err
+		}
+	}
+	if err := ob.checkMergeLevels(copies, prices); err != nil {
+		return err
+	}
+	if err := ob.journalOrders(opMerge, copies, prices...); err != nil {
+		return err
+	}
+
+	ob.merge(copies, prices)
+	return nil
+}
+
+func (ob *Orderbook) merge(orders []*Order, prices []float64) {
+	for i, o := range orders {
+		ob.placeLimitOrder(prices[i], o)
+		ob.settle()
+	}
+}
+
+// The level cap for the merge as a whole: checking the orders one by one
+// only sees the levels already in the book, not the ones earlier orders of
+// the merge are about to add
+func (ob *Orderbook) checkMergeLevels(orders []*Order, prices []float64) error {
+	if ob.MaxLevelsPerSide <= 0 || ob.EvictWorstLevel {
+		return nil
+	}
+
+	newLevels := map[bool]map[float64]bool{true: {}, false: {}}
+	for i, o := range orders {
+		limits := ob.AskLimits
+		if o.Bid {
+			limits = ob.BidLimits
+		}
+		if _, ok := limits[prices[i]]; ok || ob.fillableVolume(o, prices[i]) >= o.Size+o.Hidden {
+			continue
+		}
+		newLevels[o.Bid][prices[i]] = true
+	}
+
+	for _, bid := range []bool{false, true} {
+		if ob.levels(bid).Len()+len(newLevels[bid]) > ob.MaxLevelsPerSide {
+			return ErrBookFull
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package orderbook
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMergeWithoutCrossing(t *testing.T) {
+	ob := NewOrderBook()
+	first := NewOrder(false, 1)
+	ob.PlaceLimitOrder(101, first)
+	ob.PlaceLimitOrder(99, NewOrder(true, 2))
+
+	other := NewOrderBook()
+	second := NewOrder(false, 3, WithTraderID("alice"))
+	iceberg := NewOrder(true, 10, WithDisplaySize(2))
+	other.PlaceLimitOrder(101, second)
+	other.PlaceLimitOrder(102, NewOrder(false, 4))
+	other.PlaceLimitOrder(98, iceberg)
+
+	assert(t, ob.Merge(other), nil)
+	assert(t, len(ob.Trades()), 0)
+	assert(t, ob.ToSpec(), "S 4 @ 102\nS 1 @ 101\nS 3 @ 101\nB 2 @ 99\nB 2 @ 98\n")
+	assert(t, ob.Asks()[0].Orders[0], first) // already here, so ahead
+	assert(t, ob.Asks()[0].Orders[1].ID, second.ID)
+	assert(t, ob.OpenOrders("alice")[0].ID, second.ID)
+	assert(t, ob.Orders[iceberg.ID].Hidden, 8.0)
+	assert(t, ob.NumOrders(), 5)
+	assert(t, ob.Verify(), nil)
+
+	// other still has its own orders
+	assert(t, other.NumOrders(), 3)
+	assert(t, second.Limit, other.BestAsk())
+}
+
+func TestMergeCrossing(t *testing.T) {
+	ob := NewOrderBook()
+	ask := NewOrder(false, 2)
+	ob.PlaceLimitOrder(100, ask)
+	ob.PlaceLimitOrder(101, NewOrder(false, 2))
+
+	other := NewOrderBook()
+	bid := NewOrder(true, 3)
+	other.PlaceLimitOrder(100.5, bid)
+
+	assert(t, ob.Merge(other), nil)
+
+	trades := ob.Trades()
+	assert(t, len(trades), 1)
+	assert(t, trades[0].Ask, ask)
+	assert(t, trades[0].Bid.ID, bid.ID)
+	assert(t, trades[0].Price, 100.0)
+	assert(t, trades[0].SizeFilled, 2.0)
+	assert(t, ob.ToSpec(), "S 2 @ 101\nB 1 @ 100.5\n")
+	assert(t, bid.Size, 3.0) // the copy traded, not other's order
+}
+
+func TestMergeDuplicateIDs(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrderWithID(1, false, 1))
+
+	other := NewOrderBook()
+	other.PlaceLimitOrder(99, NewOrderWithID(2, true, 1))
+	other.PlaceLimitOrder(101, NewOrderWithID(1, false, 1))
+
+	assert(t, ob.Merge(other), ErrDuplicateOrderID)
+	assert(t, ob.ToSpec(), "S 1 @ 101\n")
+	assert(t, ob.Merge(ob), ErrDuplicateOrderID)
+}
+
+func TestMergeRejectedChangesNothing(t *testing.T) {
+	other := NewOrderBook()
+	other.PlaceLimitOrder(99, NewOrder(true, 1))
+	other.PlaceLimitOrder(103, NewOrder(false, 1))
+	other.PlaceLimitOrder(104, NewOrder(false, 1))
+
+	halted, _, _ := haltedBook()
+	before := halted.ToSpec()
+	assert(t, halted.Merge(other), ErrHalted)
+	assert(t, halted.ToSpec(), before)
+
+	banded := NewOrderBook()
+	banded.SetPriceBand(100, 3.5) // 103 is in, 104 isn't
+	assert(t, banded.Merge(other), ErrOutsideBand)
+	assert(t, banded.NumOrders(), 0)
+
+	// one new ask level would fit, the merge needs two
+	capped := NewOrderBook()
+	capped.MaxLevelsPerSide = 2
+	capped.PlaceLimitOrder(105, NewOrder(false, 1))
+	assert(t, capped.Merge(other), ErrBookFull)
+	assert(t, capped.ToSpec(), "S 1 @ 105\n")
+}
+
+func TestMergeJournal(t *testing.T) {
+	other := NewOrderBook()
+	other.PlaceLimitOrder(99, NewOrder(true, 1))
+	other.PlaceLimitOrder(101, NewOrder(false, 2))
+
+	failing := NewOrderBook()
+	failing.PlaceLimitOrder(100, NewOrder(false, 1))
+	failing.SetJournal(&failAfterWriter{ok: 1})
+	assert(t, failing.Merge(other) != nil, true)
+	assert(t, failing.ToSpec(), "S 1 @ 100\n")
+
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	var journal bytes.Buffer
+	ob.SetJournal(&journal)
+	assert(t, ob.Merge(other), nil)
+
+	replayed, err := Replay(&journal)
+	assert(t, err, nil)
+	assertSameBook(t, ob, replayed)
+}
@@ -0,0 +1,32 @@
+package orderbook
+
+// Hooks for exporting metrics (Prometheus and the like) without the package
+// depending on any metrics library. An adapter implements this and is set as
+// Orderbook.Metrics.
+type MetricsRecorder interface {
+	IncOrdersPlaced(bid bool)                      // a limit or market order passed validation
+	IncMatches()                                   // a match was printed
+	ObserveMatchSize(size float64)                 // its size
+	SetDepth(bid bool, levels int, volume float64) // a side after an operation
+}
+
+// A MetricsRecorder that does nothing, what a book without Metrics uses
+type NopMetrics struct{}
+
+func (NopMetrics) IncOrdersPlaced(bool)        {}
+func (NopMetrics) IncMatches()                 {}
+func (NopMetrics) ObserveMatchSize(float64)    {}
+func (NopMetrics) SetDepth(bool, int, float64) {}
+
+func (ob *Orderbook) metrics() MetricsRecorder {
+	if ob.Metrics == nil {
+		return NopMetrics{}
+	}
+	return ob.Metrics
+}
+
+func (ob *Orderbook) recordDepth() {
+	m := ob.metrics()
+	m.SetDepth(false, ob.levels(false).Len(), ob.askVolume)
+	m.SetDepth(true, ob.levels(true).Len(), ob.bidVolume)
+}
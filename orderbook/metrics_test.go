@@ -0,0 +1,68 @@
+package orderbook
+
+import "testing"
+
+type depthSample struct {
+	levels int
+	volume float64
+}
+
+// Remembers every call, the way a test double for a Prometheus adapter would
+type recordingMetrics struct {
+	placedBids, placedAsks int
+	matches                int
+	matchSizes             []float64
+	askDepth, bidDepth     depthSample
+}
+
+func (m *recordingMetrics) IncOrdersPlaced(bid bool) {
+	if bid {
+		m.placedBids++
+	} else {
+		m.placedAsks++
+	}
+}
+
+func (m *recordingMetrics) IncMatches() { m.matches++ }
+
+func (m *recordingMetrics) ObserveMatchSize(size float64) {
+	m.matchSizes = append(m.matchSizes, size)
+}
+
+func (m *recordingMetrics) SetDepth(bid bool, levels int, volume float64) {
+	if bid {
+		m.bidDepth = depthSample{levels, volume}
+	} else {
+		m.askDepth = depthSample{levels, volume}
+	}
+}
+
+func TestMetricsRecorder(t *testing.T) {
+	ob := NewOrderBook()
+	m := &recordingMetrics{}
+	ob.Metrics = m
+
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(102, NewOrder(false, 3))
+	ob.PlaceLimitOrder(99, NewOrder(true, 2))
+	ob.PlaceLimitOrder(0, NewOrder(true, 2)) // rejected, not counted
+	assert(t, m.askDepth, depthSample{2, 4})
+	assert(t, m.bidDepth, depthSample{1, 2})
+
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+
+	assert(t, m.placedAsks, 2)
+	assert(t, m.placedBids, 2)
+	assert(t, m.matches, 2)
+	assert(t, m.matchSizes, []float64{1, 1})
+	assert(t, m.askDepth, depthSample{1, 2})
+	assert(t, m.bidDepth, depthSample{1, 2})
+}
+
+func TestNopMetricsByDefault(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, ob.metrics(), MetricsRecorder(NopMetrics{}))
+
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceMarketOrder(NewOrder(true, 1))
+}
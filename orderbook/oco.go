@@ -0,0 +1,114 @@
+package orderbook
+
+import "errors"
+
+var ErrOCOSameOrder = errors.New("both legs of an OCO pair are the same order")
+
+// Places two linked orders where the first one to trade, even partially,
+// cancels the other. A leg with Stop set is parked as a stop order, its price
+// becomes the stop-limit price (0 for a stop-market). Both legs are checked
+// before either is placed. If the first leg trades straight away the second
+// is never placed.
+func (ob *Orderbook) PlaceOCO(a, b *Order, priceA, priceB float64) (ocoID int64, err error) {
+	if a == b {
+		return 0, ErrOCOSameOrder
+	}
+	if err := ob.allow(a); err != nil {
+		return 0, err // one request, so the rate limit counts it once
+	}
+	if err := ob.validateOCOLeg(a, priceA); err != nil {
+		return 0, err
+	}
+	if err := ob.validateOCOLeg(b, priceB); err != nil {
+		return 0, err
+	}
+	if err := ob.journalOrders(opOCO, []*Order{a, b}, priceA, priceB); err != nil {
+		return 0, err
+	}
+
+	ob.nextOCOID++
+	ocoID = ob.nextOCOID
+
+	a.OCOID = ocoID
+	b.OCOID = ocoID
+	ob.ocoSiblings[a.ID] = b
+	ob.ocoSiblings[b.ID] = a
+
+	ob.placeOCOLeg(a, priceA)
+
+	if _, linked := ob.ocoSiblings[b.ID]; !linked {
+		return ocoID, nil // a traded and b was never placed
+	}
+
+	ob.placeOCOLeg(b, priceB)
+
+	return ocoID, nil
+}
+
+func (ob *Orderbook) validateOCOLeg(o *Order, price float64) error {
+	if err := ob.validateSize(o.Size); err != nil {
+		return err
+	}
+	if o.Stop && validatePrice(o.StopPrice) != nil {
+		return ErrInvalidStopPrice
+	}
+	if err := ob.admit(o, price, !o.Stop); err != nil {
+		return err // a stop leg is checked again once it triggers
+	}
+	if o.Stop && price == 0 {
+		return nil // stop-market leg
+	}
+	if err := validatePrice(price); err != nil {
+		return err
+	}
+	if ob.TickSize > 0 && !isMultiple(price, ob.TickSize) {
+		return ErrInvalidTick
+	}
+	return nil
+}
+
+func (ob *Orderbook) placeOCOLeg(o *Order, price float64) {
+	if o.Stop {
+		o.LimitPrice = price
+		ob.stops = append(ob.stops, o)
+	} else {
+		ob.placeLimitOrder(price, o)
+	}
+
+	ob.settle()
+}
+
+// Called for every order that takes part in a match
+func (ob *Orderbook) ocoTraded(o *Order) {
+	sibling, ok := ob.ocoSiblings[o.ID]
+	if !ok {
+		return
+	}
+
+	ob.unlinkOCO(o)
+	ob.ocoCancels = append(ob.ocoCancels, sibling)
+}
+
+// Cancels the siblings of OCO legs that traded. This runs after matching is
+// done so we never pull an order out of a limit we are still filling.
+func (ob *Orderbook) cancelOCOSiblings() {
+	for len(ob.ocoCancels) > 0 {
+		sibling := ob.ocoCancels[0]
+		ob.ocoCancels = ob.ocoCancels[1:]
+
+		if sibling.Limit != nil || sibling.Stop {
+			ob.cancelOrder(sibling)
+			sibling.Status = StatusCancelled
+		}
+	}
+}
+
+func (ob *Orderbook) unlinkOCO(o *Order) {
+	sibling, ok := ob.ocoSiblings[o.ID]
+	if !ok {
+		return
+	}
+
+	delete(ob.ocoSiblings, o.ID)
+	delete(ob.ocoSiblings, sibling.ID)
+}
@@ -0,0 +1,105 @@
+package orderbook
+
+import "testing"
+
+func TestOCOLimitFillCancelsStop(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(true, 5))
+
+	takeProfit := NewOrder(false, 2)
+	stopLoss := NewOrder(false, 2, WithStopPrice(90))
+	ocoID, err := ob.PlaceOCO(takeProfit, stopLoss, 110, 0)
+
+	assert(t, err, nil)
+	assert(t, takeProfit.OCOID, ocoID)
+	assert(t, stopLoss.OCOID, ocoID)
+	assert(t, ob.PendingStops(), []*Order{stopLoss})
+
+	// A partial fill of the take profit is enough to pull the stop
+	matches, _ := ob.PlaceLimitOrder(110, NewOrder(true, 1))
+
+	assert(t, len(matches), 1)
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, takeProfit.Size, 1.0)
+	assert(t, ob.Orders[takeProfit.ID], takeProfit)
+	assert(t, len(ob.ocoSiblings), 0)
+}
+
+func TestOCOStopTriggerCancelsLimit(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(95, NewOrder(true, 5))
+	ob.PlaceLimitOrder(90, NewOrder(true, 5))
+
+	takeProfit := NewOrder(false, 2)
+	stopLoss := NewOrder(false, 2, WithStopPrice(95))
+	ob.PlaceOCO(takeProfit, stopLoss, 110, 0)
+
+	// Trading at 95 fires the stop, whose fill cancels the take profit
+	matches, _ := ob.PlaceMarketOrder(NewOrder(false, 1))
+
+	assert(t, len(matches), 2)
+	assert(t, matches[1].Ask, stopLoss)
+	assert(t, takeProfit.Limit == nil, true)
+	_, ok := ob.Orders[takeProfit.ID]
+	assert(t, ok, false)
+	assert(t, len(ob.Asks()), 0)
+}
+
+func TestOCOFirstLegTradesImmediately(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+	legA := NewOrder(true, 1)
+	legB := NewOrder(true, 1)
+	_, err := ob.PlaceOCO(legA, legB, 100, 90)
+
+	assert(t, err, nil)
+	assert(t, legA.IsFilled(), true)
+	assert(t, legB.Limit == nil, true)
+	assert(t, len(ob.Bids()), 0)
+}
+
+func TestOCOValidation(t *testing.T) {
+	ob := NewOrderBook()
+	legA := NewOrder(true, 1)
+
+	_, err := ob.PlaceOCO(legA, NewOrder(true, 0), 100, 90)
+	assert(t, err, ErrInvalidSize)
+
+	_, err = ob.PlaceOCO(legA, legA, 100, 90)
+	assert(t, err, ErrOCOSameOrder)
+
+	assert(t, len(ob.Orders), 0)
+}
+
+func TestOCOSurvivesAmend(t *testing.T) {
+	ob := NewOrderBook()
+	takeProfit := NewOrder(false, 2)
+	stopLoss := NewOrder(false, 2, WithStopPrice(90))
+	ob.PlaceOCO(takeProfit, stopLoss, 110, 0)
+
+	assert(t, ob.AmendOrder(takeProfit.ID, 108, 2), nil)
+	ob.PlaceLimitOrder(108, NewOrder(true, 1))
+
+	assert(t, takeProfit.Size, 1.0)
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, stopLoss.Status, StatusCancelled)
+}
+
+func TestOCOSurvivesReprice(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+
+	pegged := NewOrder(true, 2, WithPeg(PegBid, 0))
+	stop := NewOrder(true, 2, WithStopPrice(105))
+	ob.PlaceOCO(pegged, stop, 99, 0)
+
+	// A better bid moves the pegged leg up to 100, behind it
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, pegged.Limit.Price, 100.0)
+
+	ob.PlaceMarketOrder(NewOrder(false, 2))
+	assert(t, pegged.Size, 1.0)
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, stop.Status, StatusCancelled)
+}
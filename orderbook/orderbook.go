@@ -1,42 +1,153 @@
 package orderbook
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"math/rand"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
+var (
+	ErrInvalidSize  = errors.New("order size must be a positive, finite number")
+	ErrInvalidPrice = errors.New("limit price must be a positive, finite number")
+	ErrInvalidTick  = errors.New("limit price is not a multiple of the tick size")
+	ErrInvalidLot   = errors.New("order size is not a multiple of the lot size")
+	ErrBelowMinSize = errors.New("order size is below the minimum order size")
+
+	ErrNotEnoughVolume = errors.New("not enough volume on the book for the market order")
+)
+
 type Match struct {
 	Ask        *Order // The asking price from a *seller*
 	Bid        *Order // The bidding price from a *buyer*
 	SizeFilled float64
 	Price      float64
+	Timestamp  int64
+	MakerFee   float64 // Fee charged to the resting order, in quote currency
+	TakerFee   float64 // Fee charged to the incoming order, in quote currency
+
+	// Whether this match used up the rest of the resting (maker) or incoming
+	// (taker) order. An iceberg with reserve left isn't done yet.
+	MakerFilled bool
+	TakerFilled bool
+
+	BuyInitiated bool // the incoming (taker) order was the buy
 }
 
 // Individual order placed by a trader
 type Order struct {
 	ID        int64
+	TraderID  string // Who placed the order, empty means anonymous
 	Size      float64
 	Bid       bool // Bid is a buy order, ask is a sell order
 	Limit     *Limit
 	Timestamp int64
+
+	TimeInForce TimeInForce
+	ExpireAt    int64 // unix nanos after which a GTD order is cancelled, 0 means never
+	ReduceOnly  bool  // can only shrink the trader's position, see PlaceReduceOnly
+	AON         bool  // all or none, only ever trades its whole remaining size in one go
+
+	// Worst prices a market order accepts, buys stop filling above MaxPrice and
+	// sells below MinPrice. 0 means no protection.
+	MaxPrice float64
+	MinPrice float64
+
+	// Market-to-limit: whatever a protected market order can't fill rests at
+	// its protection price instead of being dropped
+	RestRemainder bool
+
+	Stop      bool    // waits off the book until the market trades through StopPrice
+	StopPrice float64 // buy stops trigger at last price >= StopPrice, sell stops at <=
+
+	DisplaySize float64 // iceberg orders only show this much of their size on the book
+	Hidden      float64 // iceberg reserve that refills the visible size as it trades
+
+	OCOID int64 // set when the order is one leg of a one-cancels-the-other pair
+
+	Peg       Peg     // pegged orders follow a reference price instead of sitting at a fixed one
+	PegOffset float64 // added to the reference price, e.g. -0.5 to sit half a point under the bid
+
+	// For stop-limit orders, the price of the limit order placed once the stop
+	// triggers. 0 means the stop goes in as a market order. Scheduled orders
+	// use it the same way.
+	LimitPrice float64
+
+	ActivateAt int64 // unix nanos when a scheduled order goes in, see PlaceScheduledOrder
+
+	Status OrderStatus // where the order is in its lifecycle
+
+	tiebreak int64   // orders queue by this when their timestamps are equal, see tiebreak.go
+	price    float64 // limit price while the order is matching, 0 for market orders
+}
+
+// Optional settings that can be passed to NewOrder
+type OrderOption func(*Order)
+
+// Sets the trader that owns the order
+func WithTraderID(traderID string) OrderOption {
+	return func(o *Order) {
+		o.TraderID = traderID
+	}
+}
+
+// Makes the order an iceberg that only shows displaySize at a time
+func WithDisplaySize(displaySize float64) OrderOption {
+	return func(o *Order) {
+		o.DisplaySize = displaySize
+	}
 }
 
 type Orders []*Order
 
 func (o Orders) Len() int           { return len(o) }
 func (o Orders) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
-func (o Orders) Less(i, j int) bool { return o[i].Timestamp < o[j].Timestamp }
+func (o Orders) Less(i, j int) bool { return o[i].ahead(o[j]) }
 
 // Creates a new Order
-func NewOrder(bid bool, size float64) *Order {
-	return &Order{
-		ID:        int64(rand.Intn(1000000000000)), // TODO: Implement better ID system then random numbers
+func NewOrder(bid bool, size float64, opts ...OrderOption) *Order {
+	return NewOrderWithID(nextOrderID(), bid, size, opts...)
+}
+
+// Same as NewOrder but with an id of your choosing, for fully deterministic setups
+func NewOrderWithID(id int64, bid bool, size float64, opts ...OrderOption) *Order {
+	o := &Order{
+		ID:        id,
 		Size:      size,
 		Bid:       bid,
 		Timestamp: time.Now().UnixNano(),
 	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+var (
+	orderIDsMu sync.Mutex
+	orderIDs   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Makes NewOrder hand out the same ids in the same order on every run, so
+// simulations and backtests are reproducible
+func SeedOrderIDs(seed int64) {
+	orderIDsMu.Lock()
+	defer orderIDsMu.Unlock()
+	orderIDs = rand.New(rand.NewSource(seed))
+}
+
+func nextOrderID() int64 {
+	orderIDsMu.Lock()
+	defer orderIDsMu.Unlock()
+	return int64(orderIDs.Intn(1000000000000)) // TODO: Implement better ID system then random numbers
 }
 
 func (o *Order) String() string {
@@ -56,6 +167,10 @@ type Limit struct {
 	Price       float64
 	Orders      Orders
 	TotalVolume float64
+
+	book      *Orderbook // set when the limit lives in an order book, nil for standalone limits
+	createdAt int64      // book clock when the first order arrived
+	index     int        // position in its side's heap, see heapLevels
 }
 
 type Limits []*Limit
@@ -80,11 +195,22 @@ func NewLimit(price float64) *Limit {
 	}
 }
 
-// Adds an order to a specific price level
+// Adds an order to a specific price level. Iceberg orders only show their
+// DisplaySize, the rest is kept in Hidden.
 func (l *Limit) AddOrder(o *Order) {
+	if o.DisplaySize > 0 && o.Size > o.DisplaySize {
+		o.Hidden += o.Size - o.DisplaySize
+		o.Size = o.DisplaySize
+	}
+
 	o.Limit = l
 	l.Orders = append(l.Orders, o)
-	l.TotalVolume += o.Size
+	l.placeAmongTies(o)
+	l.addVolume(o.Bid, o.Size)
+
+	if l.book != nil {
+		l.book.publishOrder(OrderAdded, o, l.Price)
+	}
 }
 
 // Removes an order from a specific price level i.e. you want to cancel an order
@@ -97,44 +223,158 @@ func (l *Limit) DeleteOrder(o *Order) {
 	}
 
 	o.Limit = nil
-	l.TotalVolume -= o.Size
+	l.addVolume(o.Bid, -o.Size)
 
 	sort.Sort(l.Orders)
+
+	if l.book != nil {
+		l.book.publishOrder(OrderRemoved, o, l.Price)
+	}
+}
+
+// Calls fn for every order at the level in time priority, stops as soon as fn returns false
+func (l *Limit) ForEachOrder(fn func(*Order) bool) {
+	for _, o := range l.Orders {
+		if !fn(o) {
+			return
+		}
+	}
 }
 
 func (l *Limit) Fill(o *Order) []Match {
-	var (
-		matches        []Match
-		ordersToDelete []*Order
-	)
+	if l.matchingMode() != FIFO {
+		return l.fillProRata(o)
+	}
+
+	var matches []Match
+
+	for {
+		var (
+			ordersToDelete []*Order
+			refreshed      bool
+		)
+
+		for _, order := range l.Orders {
+			if isSelfTrade(order, o) {
+				if l.preventSelfTrade(order, o) {
+					ordersToDelete = append(ordersToDelete, order)
+				}
+				if o.done() {
+					break
+				}
+				continue
+			}
+			if !canTakeAll(o.Size, order) {
+				continue // step over it, the orders behind it can still trade
+			}
 
-	for _, order := range l.Orders {
-		match := l.fillOrder(order, o)
-		matches = append(matches, match)
+			match, ok := l.fillOrder(order, o)
+			if ok {
+				matches = append(matches, match)
+				l.addVolume(order.Bid, -match.SizeFilled)
+			}
 
-		l.TotalVolume -= match.SizeFilled
+			if order.IsFilled() {
+				ordersToDelete = append(ordersToDelete, order)
+			}
 
-		if order.IsFilled() {
-			ordersToDelete = append(ordersToDelete, order)
+			if o.IsFilled() {
+				break
+			}
 		}
 
-		if o.IsFilled() {
-			break
+		for _, order := range ordersToDelete {
+			if order.IsFilled() && order.Hidden > 0 {
+				l.refreshPeak(order)
+				refreshed = true
+				continue
+			}
+
+			l.DeleteOrder(order)
+
+			if l.book != nil {
+				l.book.untrackOrder(order)
+			}
+		}
+
+		// A refreshed iceberg peak sits at the back of the queue and can still be hit
+		if !refreshed || o.done() {
+			return matches
 		}
 	}
+}
+
+// Shows the next peak of an iceberg order whose visible size just filled. The
+// new peak goes to the back of the queue like a new order would.
+func (l *Limit) refreshPeak(o *Order) {
+	l.DeleteOrder(o)
+
+	peak := math.Min(o.DisplaySize, o.Hidden)
+	o.Hidden -= peak
+	o.Size = peak
+	o.Timestamp = l.timestamp()
+
+	l.AddOrder(o)
+}
 
-	for _, order := range ordersToDelete {
-		l.DeleteOrder(order)
+// The book's timestamp for the operation in progress, see stamp. A standalone
+// limit goes by the wall clock.
+func (l *Limit) timestamp() int64 {
+	if l.book == nil {
+		return time.Now().UnixNano()
 	}
+	return l.book.stamp()
+}
 
-	return matches
+// Changes the level's volume and keeps the book's running total for that side in step
+func (l *Limit) addVolume(bid bool, delta float64) {
+	l.TotalVolume += delta
+
+	if l.book == nil {
+		return
+	}
+	if bid {
+		l.book.bidVolume += delta
+	} else {
+		l.book.askVolume += delta
+	}
+}
+
+// Two orders from the same (non anonymous) trader should never trade with each other
+func isSelfTrade(resting, incoming *Order) bool {
+	return incoming.TraderID != "" && resting.TraderID == incoming.TraderID
+}
+
+func (l *Limit) selfTradePolicy() STPPolicy {
+	if l.book == nil {
+		return STPSkip
+	}
+	return l.book.STP
+}
+
+func (l *Limit) fillOrder(a, b *Order) (Match, bool) {
+	return l.fillOrderSize(a, b, math.Min(a.Size, b.Size))
+}
+
+// Trades size between the resting order a and the incoming order b. Nothing
+// trades, and false comes back, when there is nothing to trade (an order that
+// is already filled but still queued) or both orders are on the same side.
+func (l *Limit) fillOrderSize(a, b *Order, size float64) (Match, bool) {
+	return l.fillAt(a, b, size, l.tradePrice(b))
 }
 
-func (l *Limit) fillOrder(a, b *Order) Match {
+// Same as fillOrderSize, but at a price given by the caller
+func (l *Limit) fillAt(a, b *Order, size, price float64) (Match, bool) {
+	if size <= 0 || a.Size <= 0 || b.Size <= 0 || a.Bid == b.Bid {
+		return Match{}, false
+	}
+	if size, price = l.roundFill(a, b, size, price); size <= 0 {
+		return Match{}, false
+	}
+
 	var (
-		bid        *Order
-		ask        *Order
-		sizeFilled float64
+		bid *Order
+		ask *Order
 	)
 
 	if a.Bid {
@@ -145,75 +385,354 @@ func (l *Limit) fillOrder(a, b *Order) Match {
 		ask = a
 	}
 
-	if a.Size >= b.Size {
-		a.Size -= b.Size
-		sizeFilled = b.Size
-		b.Size = 0.0
-	} else {
-		b.Size -= a.Size
-		sizeFilled = a.Size
-		a.Size = 0.0
-	}
+	a.Size -= size
+	b.Size -= size
+	a.updateFillStatus()
+	b.updateFillStatus()
 
-	return Match{
+	match := Match{
 		Bid:        bid,
 		Ask:        ask,
-		SizeFilled: sizeFilled,
-		Price:      l.Price,
+		SizeFilled: size,
+		Price:      price,
+		Timestamp:  l.timestamp(),
+
+		MakerFilled: a.Status == StatusFilled,
+		TakerFilled: b.Status == StatusFilled,
+
+		BuyInitiated: b.Bid,
 	}
+
+	if l.book != nil {
+		l.book.chargeFees(&match, a, b)
+		l.book.recordTrade(match)
+		if !a.IsFilled() {
+			l.book.publishOrder(OrderModified, a, l.Price)
+		}
+		l.book.ocoTraded(a)
+		l.book.ocoTraded(b)
+	}
+
+	return match, true
 }
 
+// What happens when an incoming order would match a resting order from the same trader
+type STPPolicy int
+
+const (
+	STPSkip               STPPolicy = iota // leave the resting order alone and try the next one
+	STPCancelOldest                        // cancel the resting order and keep matching
+	STPCancelNewest                        // cancel what's left of the incoming order, the default
+	STPCancelBoth                          // cancel both orders
+	STPDecrementAndCancel                  // take the smaller size off both and cancel the smaller order
+
+	STPCancelResting = STPCancelOldest
+)
+
 // The entire order book
 type Orderbook struct {
-	asks []*Limit
-	bids []*Limit
+	asks LevelStore // best (lowest) price first, see levels
+	bids LevelStore // best (highest) price first
+
+	newLevelStore func(bid bool) LevelStore // nil means defaultLevelStore
 
 	AskLimits map[float64]*Limit
 	BidLimits map[float64]*Limit
 	Orders    map[int64]*Order //used for api id accessing
+
+	askVolume float64 // running sum of TotalVolume over the ask levels
+	bidVolume float64 // same for the bids
+
+	traderOrders map[string][]*Order // resting orders per trader
+
+	STP      STPPolicy    // self-trade prevention policy
+	Matching MatchingMode // how a level shares incoming orders out, FIFO by default
+	Fees     *FeeSchedule // nil means trading is free
+	Rounding *Rounding    // nil means fills aren't rounded
+	TickSize float64      // limit prices must be a multiple of this, 0 means any price
+	LotSize  float64      // order sizes must be a multiple of this, 0 means any size
+	MinSize  float64      // smallest size an order can have
+
+	// What price a crossing order trades at, the resting order's by default
+	PriceImprovement PriceImprovementMode
+
+	// With ProRataTopOrder matching, the fraction (0 to 1) of an incoming
+	// order the oldest resting order at the level is guaranteed
+	TopOrderAllocation float64
+
+	// Some venues treat a limit order priced exactly at the opposite best price
+	// as passive, so it rests (locking the book) instead of taking liquidity
+	RestAtEqualPrice bool
+
+	// Caps how many price levels each side can have, 0 means no cap. A limit
+	// order that would open a new level on a full side is rejected with
+	// ErrBookFull, or with EvictWorstLevel it pushes out the worst level.
+	MaxLevelsPerSide int
+	EvictWorstLevel  bool
+
+	// Set to queue orders that arrive in the same nanosecond in random order
+	// instead of by id. Only simulations should want this.
+	TiebreakRand *rand.Rand
+
+	// Halts trading once a trade prints more than this many percent away from
+	// the price band's reference, 0 means never. See SetPriceBand.
+	CircuitBreakerPct float64
+
+	// Asked whether the trader can afford each order before it's placed, nil
+	// means every order is affordable
+	Accounts AccountChecker
+
+	// Gets told about placements, matches and depth, nil records nothing
+	Metrics MetricsRecorder
+
+	// How many operations back StateAtSeq can reach, 0 keeps no history
+	HistorySize int
+
+	// Throttles placements and cancels per trader, nil means no limit
+	RateLimiter RateLimiter
+
+	// Also puts an order level (L3) event on the feed for every order added to,
+	// changed on or removed from the book. Goes with the feed, so Clear leaves it.
+	OrderEvents bool
+
+	// How long last price samples are kept around for TWAP, 0 keeps them all
+	PriceSampleRetention time.Duration
+
+	// Most trades Trades keeps, 0 keeps them all. TradeHistoryPolicy says
+	// what happens once there are that many.
+	TradeHistorySize   int
+	TradeHistoryPolicy HistoryPolicy
+
+	trades       []Match       // the tape, oldest first
+	priceSamples []priceSample // every change of the last price, oldest first
+
+	// Running totals for Stats, kept apart from the tape so they stay cheap
+	tradeCount     int
+	tradedVolume   float64
+	tradedNotional float64
+
+	accrued map[string]feeAccrual // fees and rebates per trader, for AccruedFees
+
+	userFills map[string][]traderFill // each trader's fills still on the tape, for UserVolume
+
+	now func() time.Time
+
+	levelSurvival []time.Duration  // how long each cleared level lived
+	stops         []*Order         // stop orders waiting for their trigger, oldest first
+	scheduled     []*Order         // scheduled orders waiting for their ActivateAt
+	pegged        []*Order         // resting orders that follow the top of the book
+	ocoSiblings   map[int64]*Order // order id -> the other leg of its OCO pair
+	ocoCancels    []*Order         // OCO legs to cancel once matching is done
+	nextOCOID     int64
+
+	feed          chan FeedMessage
+	feedSeq       int64
+	touched       []touchedLevel   // levels changed by the operation in progress
+	levelChanges  []LevelChange    // levels changed since LevelChanges was last called
+	levelChangeAt map[levelKey]int // index into levelChanges, nil while nobody tracks them
+
+	journalWriter io.Writer
+	journalErr    error // first failed journal write, every later placement returns it
+	opTime        int64 // when the operation in progress was journaled, see stamp
+
+	history []historySegment // see StateAtSeq
+	opSeq   int64
+
+	logger *slog.Logger // nil means nothing is logged
+
+	onTopChange    func(bid, ask *Limit) // see OnTopOfBookChange
+	topBid, topAsk float64               // best prices last reported to onTopChange
+	candleStreams  []*CandleStream       // see StreamCandles
+
+	bandRef float64 // see SetPriceBand
+	bandPct float64
+	halted  bool
+	auction bool // see StartAuction
+
+	mu         sync.Mutex    // see Lock
+	expiryStop chan struct{} // closed by Close to stop the expiry loop
+	expiryDone chan struct{} // closed by the expiry loop once it stopped
 }
 
+// Makes the LevelStore for each side of the books NewOrderBook creates
+var defaultLevelStore = NewSliceLevelStore
+
 func NewOrderBook() *Orderbook {
+	return NewOrderBookWithLevelStore(defaultLevelStore)
+}
+
+// A book whose sides are kept in stores made by newStore, e.g. to try out a
+// different data structure for the price levels
+func NewOrderBookWithLevelStore(newStore func(bid bool) LevelStore) *Orderbook {
 	return &Orderbook{
-		asks:      []*Limit{},
-		bids:      []*Limit{},
+		asks:          newStore(false),
+		bids:          newStore(true),
+		newLevelStore: newStore,
+
 		AskLimits: make(map[float64]*Limit),
 		BidLimits: make(map[float64]*Limit),
 		Orders:    make(map[int64]*Order),
+
+		traderOrders: make(map[string][]*Order),
+		ocoSiblings:  make(map[int64]*Order),
+		now:          time.Now,
+		STP:          STPCancelNewest,
 	}
 }
 
-// Always fills the best price. Starts at a certain Limit level until it is completely gone, then it will go ti the next level
-func (ob *Orderbook) PlaceMarketOrder(o *Order) []Match {
-	// Unless the exchange has no volume,
-	matches := []Match{}
+// Drops every order, price level and trade while keeping the book's
+// configuration (STP policy, fee schedule, tick/lot/min size, clock) as it is
+func (ob *Orderbook) Reset() {
+	ob.journal(journalRecord{Op: opReset})
+	ob.reset()
+}
 
-	if o.Bid {
-		if o.Size > ob.AskTotalVolume() {
-			panic(fmt.Errorf("not enough volume [size: %.2f] for market order [size: %.2f]", ob.AskTotalVolume(), o.Size))
+// Puts the book back the way NewOrderBook made it: like Reset, but the
+// configuration goes back to the defaults as well. A feed or journal that is
+// attached stays attached.
+func (ob *Orderbook) Clear() {
+	ob.journal(journalRecord{Op: opClear})
+	ob.reset()
+
+	ob.STP = STPCancelNewest
+	ob.Matching = FIFO
+	ob.PriceImprovement = PriceAtMaker
+	ob.TopOrderAllocation = 0
+	ob.Fees = nil
+	ob.Rounding = nil
+	ob.TickSize = 0
+	ob.LotSize = 0
+	ob.MinSize = 0
+	ob.RestAtEqualPrice = false
+	ob.MaxLevelsPerSide = 0
+	ob.EvictWorstLevel = false
+	ob.RateLimiter = nil
+	ob.Accounts = nil
+	ob.Metrics = nil
+	ob.HistorySize = 0
+	ob.CircuitBreakerPct = 0
+	ob.bandRef = 0
+	ob.bandPct = 0
+	ob.TiebreakRand = nil
+	ob.PriceSampleRetention = 0
+	ob.TradeHistorySize = 0
+	ob.TradeHistoryPolicy = DropOldest
+	ob.now = time.Now
+}
+
+func (ob *Orderbook) reset() {
+	for _, o := range ob.AllOrders() {
+		ob.publishOrder(OrderRemoved, o, o.Limit.Price)
+	}
+	for _, o := range ob.Orders {
+		o.Limit = nil
+	}
+	for _, bid := range []bool{false, true} {
+		for _, l := range ob.limits(bid) {
+			l.book = nil
+			ob.touch(bid, l)
 		}
+	}
 
-		// we use the Asks() func (not the private var) so we get the sorted lists of asks
-		for _, limit := range ob.Asks() {
-			limitMatches := limit.Fill(o)
-			matches = append(matches, limitMatches...)
+	ob.asks = nil // made again on first use
+	ob.bids = nil
+	ob.askVolume = 0
+	ob.bidVolume = 0
+	ob.AskLimits = make(map[float64]*Limit)
+	ob.BidLimits = make(map[float64]*Limit)
+	ob.Orders = make(map[int64]*Order)
+	ob.traderOrders = make(map[string][]*Order)
+	ob.trades = nil
+	ob.priceSamples = nil
+	ob.tradeCount = 0
+	ob.userFills = nil
+	ob.tradedVolume = 0
+	ob.tradedNotional = 0
+	ob.accrued = nil
+	ob.levelSurvival = nil
+	ob.stops = nil
+	ob.scheduled = nil
+	ob.pegged = nil
+	ob.ocoSiblings = make(map[int64]*Order)
+	ob.ocoCancels = nil
+	ob.halted = false
+	ob.auction = false
+	ob.clearLevelChanges()
 
-			if len(limit.Orders) == 0 {
-				ob.clearLimit(true, limit)
-			}
+	ob.flushDepth() // every level we had is now reported as gone
+}
+
+// Replaces the clock used to timestamp trades, mostly useful for tests
+func (ob *Orderbook) SetClock(now func() time.Time) {
+	ob.now = now
+}
+
+// Always fills the best price. Starts at a certain Limit level until it is completely gone, then it will go ti the next level
+func (ob *Orderbook) PlaceMarketOrder(o *Order) (matches []Match, err error) {
+	defer ob.recoverPanic(opMarket, &err)
+
+	// the remainder of a market-to-limit order rests at the protection price
+	if err := ob.checkPlacement(o, o.protectionPrice(), o.RestRemainder); err != nil {
+		return nil, err
+	}
+	if ob.auction {
+		return nil, ErrInAuction
+	}
+	if err := ob.validateSize(o.Size); err != nil {
+		return nil, err
+	}
+	if o.RestRemainder {
+		if err := ob.validateLimit(o.protectionPrice(), o); err != nil {
+			return nil, err
 		}
-	} else {
-		if o.Size > ob.BidTotalVolume() {
-			panic(fmt.Errorf("not enough volume [size: %.2f] for market order [size: %.2f]", ob.BidTotalVolume(), o.Size))
+	}
+
+	// Unless the exchange has no volume, a protected order stops at its worst price anyway
+	if o.protectionPrice() == 0 && !ob.hasVolume(!o.Bid, o.Size) {
+		return nil, ErrNotEnoughVolume
+	}
+	if err := ob.journalOrders(opMarket, []*Order{o}); err != nil {
+		return nil, err
+	}
+	ob.metrics().IncOrdersPlaced(o.Bid)
+
+	matches = ob.placeMarketOrder(o)
+	matches = append(matches, ob.settle()...)
+
+	return matches, nil
+}
+
+// Fills as much of the order as the book allows, anything left over is dropped
+func (ob *Orderbook) placeMarketOrder(o *Order) []Match {
+	ob.debug("order placed", "id", o.ID, "bid", o.Bid, "size", o.Size, "type", "market")
+	matches := ob.match(o, o.withinProtection)
+
+	if o.RestRemainder && o.protectionPrice() > 0 && !o.done() {
+		return append(matches, ob.placeLimitOrder(o.protectionPrice(), o)...)
+	}
+
+	ob.flushDepth()
+	return matches
+}
+
+// Walks the other side from the best price, filling o at every level canFill
+// accepts. Emptied levels are cleared, levels we can only skip (our own orders)
+// are stepped over.
+func (ob *Orderbook) match(o *Order, canFill func(price float64) bool) []Match {
+	matches := []Match{}
+
+	var limit *Limit
+	for !o.done() {
+		limit = ob.nextLevel(!o.Bid, limit)
+		if limit == nil || !canFill(limit.Price) {
+			break
 		}
 
-		// we use the Asks() func (not the private var) so we get the sorted lists of asks
-		for _, limit := range ob.Bids() {
-			limitMatches := limit.Fill(o)
-			matches = append(matches, limitMatches...)
-			if len(limit.Orders) == 0 {
-				ob.clearLimit(true, limit)
-			}
+		ob.touch(!o.Bid, limit)
+		matches = append(matches, limit.Fill(o)...)
+
+		if len(limit.Orders) == 0 {
+			ob.clearLimit(!o.Bid, limit)
 		}
 	}
 
@@ -222,123 +741,526 @@ func (ob *Orderbook) PlaceMarketOrder(o *Order) []Match {
 
 // An order for a specific price point.
 // PlaceLimitOrder places a limit order and returns any matches.
-func (ob *Orderbook) PlaceLimitOrder(price float64, o *Order) []Match {
-	var limit *Limit
-	matches := []Match{}
+func (ob *Orderbook) PlaceLimitOrder(price float64, o *Order) (matches []Match, err error) {
+	defer ob.recoverPanic(opLimit, &err)
 
-	// If it's a buy order, look for matching sell orders (asks)
-	if o.Bid {
-		for _, askLimit := range ob.Asks() {
-			// Check if the buy order price is greater than or equal to the ask limit price
-			if price >= askLimit.Price {
-				limitMatches := askLimit.Fill(o)
-				matches = append(matches, limitMatches...)
-
-				if len(askLimit.Orders) == 0 {
-					ob.clearLimit(true, askLimit) // Clearing ask limit
-				}
+	if err := ob.checkPlacement(o, price, true); err != nil {
+		return nil, err
+	}
+	if err := ob.validateLimit(price, o); err != nil {
+		return nil, err
+	}
+	if err := ob.journalOrders(opLimit, []*Order{o}, price); err != nil {
+		return nil, err
+	}
+	ob.metrics().IncOrdersPlaced(o.Bid)
 
-				if o.IsFilled() {
-					break
-				}
-			}
-		}
+	matches = ob.placeLimitOrder(price, o)
+	matches = append(matches, ob.settle()...)
 
-		limit = ob.BidLimits[price]
-	} else { // If it's a sell order, look for matching buy orders (bids)
-		for _, bidLimit := range ob.Bids() {
-			// Check if the sell order price is less than or equal to the bid limit price
-			if price <= bidLimit.Price {
-				limitMatches := bidLimit.Fill(o)
-				matches = append(matches, limitMatches...)
-
-				if len(bidLimit.Orders) == 0 {
-					ob.clearLimit(false, bidLimit) // Clearing bid limit
-					fmt.Println("Cleared bid limit")
-				}
+	return matches, nil
+}
 
-				if o.IsFilled() {
-					break
-				}
-			}
+// Reacts to the trades an order just made: cancels OCO siblings, fires any
+// triggered stops and moves pegged orders. Returns the matches those cause.
+func (ob *Orderbook) settle() []Match {
+	ob.cancelOCOSiblings()
+	matches := ob.activateStops()
+	matches = append(matches, ob.reprice()...)
+	ob.cancelOCOSiblings()
+
+	return matches
+}
+
+// Whether one side holds at least size, iceberg reserves included. The
+// running totals only count visible size, the reserves are only added up
+// when that falls short.
+func (ob *Orderbook) hasVolume(bid bool, size float64) bool {
+	volume := ob.AskTotalVolume()
+	if bid {
+		volume = ob.BidTotalVolume()
+	}
+	if size <= volume {
+		return true
+	}
+
+	ob.levels(bid).Iterate(func(l *Limit) bool {
+		for _, o := range l.Orders {
+			volume += o.Hidden
 		}
+		return true
+	})
+	return size <= volume
+}
+
+// Matches the order against the other side and rests whatever is left
+func (ob *Orderbook) placeLimitOrder(price float64, o *Order) []Match {
+	ob.debug("order placed", "id", o.ID, "bid", o.Bid, "size", o.Size, "price", price)
+	matches := []Match{}
+	if !ob.auction && (!o.AON || ob.fillableVolume(o, price) >= o.Size) {
+		o.price = price
+		matches = ob.match(o, func(levelPrice float64) bool {
+			return ob.crosses(o.Bid, price, levelPrice)
+		})
+		o.price = 0
+	}
+
+	// IOC and FOK orders never rest, whatever didn't trade is dropped. A FOK
+	// only gets here short if the fillable check was off, it still mustn't rest.
+	if (o.TimeInForce == IOC || o.TimeInForce == FOK) && !o.done() {
+		o.Status = StatusCancelled
+	}
 
-		limit = ob.AskLimits[price]
+	limit := ob.AskLimits[price]
+	if o.Bid {
+		limit = ob.BidLimits[price]
 	}
 
-	// If the limit wasn't filled and doesn't exist, create it
-	if !o.IsFilled() {
+	// If the limit wasn't filled (or cancelled by STP) and doesn't exist, create it
+	if !o.done() {
 		if limit == nil {
+			ob.makeRoom(o.Bid, price)
 			limit = NewLimit(price)
-
-			if o.Bid {
-				ob.bids = append(ob.bids, limit)
-				ob.BidLimits[price] = limit
-			} else {
-				ob.asks = append(ob.asks, limit)
-				ob.AskLimits[price] = limit
-			}
+			limit.book = ob
+			limit.createdAt = ob.now().UnixNano()
+			ob.addLimit(o.Bid, limit)
 		}
-		ob.Orders[o.ID] = o
+		ob.touch(o.Bid, limit)
+		ob.trackOrder(o)
 		limit.AddOrder(o)
 	}
+
+	ob.flushDepth()
 	return matches // Return the matches, will be empty if no matches occurred
 }
 
+// The gate for every order a trader sends in or moves: their rate limit,
+// then admit
+func (ob *Orderbook) checkPlacement(o *Order, price float64, rests bool) error {
+	if err := ob.allow(o); err != nil {
+		return err
+	}
+	return ob.admit(o, price, rests)
+}
+
+// Whether o may go into the book at price right now: trading isn't halted,
+// the trade history has room, the trader can afford it and, if it may rest
+// at price, that price is inside the band and doesn't need a level the book
+// has no room for. Orders the book sends in or moves by itself (triggered
+// stops, scheduled orders, pegs) only go through this, the trader was rate
+// limited when they placed them.
+func (ob *Orderbook) admit(o *Order, price float64, rests bool) error {
+	if ob.halted {
+		return ErrHalted
+	}
+	if ob.tradeHistoryFull() {
+		return ErrHistoryFull
+	}
+	if err := ob.canPlace(o, price); err != nil {
+		return err
+	}
+	if !rests || validatePrice(price) != nil {
+		return nil // a bad price is validateLimit's to report
+	}
+	if err := ob.checkBand(price); err != nil {
+		return err
+	}
+	return ob.checkLevelCap(price, o)
+}
+
+// Checks a limit order against the book's rules before anything is changed
+func (ob *Orderbook) validateLimit(price float64, o *Order) error {
+	if err := ob.validateSize(o.Size); err != nil {
+		return err
+	}
+	if err := validatePrice(price); err != nil {
+		return err
+	}
+	if ob.TickSize > 0 && !isMultiple(price, ob.TickSize) {
+		return ErrInvalidTick
+	}
+	if ob.auction && (o.TimeInForce == FOK || o.TimeInForce == IOC) {
+		return ErrInAuction
+	}
+	if o.TimeInForce == FOK && ob.fillableVolume(o, price) < o.Size {
+		return ErrFillOrKill
+	}
+	return nil
+}
+
+func (ob *Orderbook) validateSize(size float64) error {
+	if size <= 0 || math.IsNaN(size) || math.IsInf(size, 0) {
+		return ErrInvalidSize
+	}
+	if size < ob.MinSize {
+		return ErrBelowMinSize
+	}
+	if ob.LotSize > 0 && !isMultiple(size, ob.LotSize) {
+		return ErrInvalidLot
+	}
+	return nil
+}
+
+// Whether an incoming limit at price can trade against a resting level at levelPrice
+func (ob *Orderbook) crosses(bid bool, price, levelPrice float64) bool {
+	if price == levelPrice {
+		return !ob.RestAtEqualPrice
+	}
+	if bid {
+		return price > levelPrice
+	}
+	return price < levelPrice
+}
+
+func validatePrice(price float64) error {
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+// Tolerance used when checking for multiples, so 100.05 / 0.01 = 10004.999999999998 still counts
+const multipleEpsilon = 1e-9
+
+func isMultiple(value, step float64) bool {
+	q := value / step
+	return math.Abs(q-math.Round(q)) <= multipleEpsilon*math.Max(1, math.Abs(q))
+}
+
 func (ob *Orderbook) clearLimit(bid bool, l *Limit) {
+	ob.debug("limit cleared", "bid", bid, "price", l.Price)
+	ob.recordSurvival(l)
+
+	// an empty level should be at zero already, this drops any rounding dust with it
 	if bid {
 		delete(ob.BidLimits, l.Price)
-		for i := 0; i < len(ob.bids); i++ {
-			if ob.bids[i] == l {
-				ob.bids[i] = ob.bids[len(ob.bids)-1]
-				ob.bids = ob.bids[:len(ob.bids)-1]
-				break
-			}
-		}
+		ob.bidVolume -= l.TotalVolume
 	} else {
 		delete(ob.AskLimits, l.Price)
-		for i := 0; i < len(ob.asks); i++ {
-			if ob.asks[i] == l {
-				ob.asks[i] = ob.asks[len(ob.asks)-1]
-				ob.asks = ob.asks[:len(ob.asks)-1]
-				break
-			}
-		}
+		ob.askVolume -= l.TotalVolume
+	}
+	ob.removeLimit(bid, l)
+}
+
+// Takes a resting order (or a pending stop) off the book. Only fails when the
+// owner is over the rate limit, the order is left alone then.
+func (ob *Orderbook) CancelOrder(o *Order) error {
+	if err := ob.allow(o); err != nil {
+		return err
 	}
+	ob.cancel(o)
+	return nil
 }
 
-func (ob *Orderbook) CancelOrder(o *Order) {
+// Cancels the live order (resting or a pending stop) with this id and returns
+// the size that was still unfilled, peak and iceberg reserve together, e.g.
+// to release the margin held against it.
+func (ob *Orderbook) CancelOrderByID(id int64) (float64, error) {
+	o := ob.findOrder(id)
+	if o == nil {
+		return 0, ErrOrderNotFound
+	}
+
+	freed := o.Size + o.Hidden
+	if err := ob.CancelOrder(o); err != nil {
+		return 0, err
+	}
+	return freed, nil
+}
+
+// Cancels on the book's own behalf (expiry, CancelAll, ...), never rate limited
+func (ob *Orderbook) cancel(o *Order) {
+	ob.journal(journalRecord{Op: opCancel, ID: o.ID}) // a failed write is reported by the next placement
+	ob.cancelOrder(o)
+	o.Status = StatusCancelled
+}
+
+func (ob *Orderbook) cancelOrder(o *Order) {
+	ob.debug("order cancelled", "id", o.ID)
+	ob.unlinkOCO(o)
+
+	if o.Stop && o.Limit == nil {
+		ob.removeStop(o) // still waiting for its trigger, not on the book yet
+		return
+	}
+	if o.Limit == nil && ob.removeScheduled(o) {
+		return // not activated yet
+	}
+
+	ob.unrest(o)
+}
+
+// Takes a resting order off its level without cancelling it, to move it
+// somewhere else. Unlike cancelOrder it stays linked to its OCO sibling.
+func (ob *Orderbook) unrest(o *Order) {
 	limit := o.Limit
+	ob.touch(o.Bid, limit)
 	limit.DeleteOrder(o)
+	ob.untrackOrder(o)
+
+	if len(limit.Orders) == 0 {
+		ob.clearLimit(o.Bid, limit)
+	}
+
+	ob.flushDepth()
+}
+
+// Registers a resting order in the id lookup and the per trader index
+func (ob *Orderbook) trackOrder(o *Order) {
+	ob.Orders[o.ID] = o
+
+	if o.Peg != PegNone {
+		ob.pegged = append(ob.pegged, o)
+	}
+
+	if o.TraderID != "" {
+		ob.traderOrders[o.TraderID] = append(ob.traderOrders[o.TraderID], o)
+	}
+}
+
+// Removes an order that left the book (filled or cancelled) from the lookups
+func (ob *Orderbook) untrackOrder(o *Order) {
 	delete(ob.Orders, o.ID)
+
+	if o.Peg != PegNone {
+		ob.removePegged(o)
+	}
+
+	orders := ob.traderOrders[o.TraderID]
+	for i := 0; i < len(orders); i++ {
+		if orders[i] == o {
+			orders = append(orders[:i], orders[i+1:]...)
+			break
+		}
+	}
+
+	if len(orders) == 0 {
+		delete(ob.traderOrders, o.TraderID)
+	} else {
+		ob.traderOrders[o.TraderID] = orders
+	}
 }
 
-func (ob *Orderbook) BidTotalVolume() float64 {
-	totalVolume := 0.0
+// All resting orders owned by a trader, in the order they were placed
+func (ob *Orderbook) OpenOrders(traderID string) []*Order {
+	orders := make([]*Order, len(ob.traderOrders[traderID]))
+	copy(orders, ob.traderOrders[traderID])
+	return orders
+}
 
-	for i := 0; i < len(ob.bids); i++ {
-		totalVolume += ob.bids[i].TotalVolume
+// Every resting order in the book: asks then bids, best price first, each
+// level in time priority. Unlike ranging over ob.Orders the result is stable.
+func (ob *Orderbook) AllOrders() []*Order {
+	orders := make([]*Order, 0, len(ob.Orders))
+	for _, bid := range []bool{false, true} {
+		ob.levels(bid).Iterate(func(l *Limit) bool {
+			orders = append(orders, l.Orders...)
+			return true
+		})
 	}
+	return orders
+}
 
-	return totalVolume
+// How many orders a trader currently has resting, used for quote throttling
+func (ob *Orderbook) QuoteCount(traderID string) int {
+	return len(ob.traderOrders[traderID])
 }
 
-func (ob *Orderbook) AskTotalVolume() float64 {
-	totalVolume := 0.0
+// How many orders are ahead of the order with this id at its price level
+func (ob *Orderbook) QueuePosition(id int64) (int, error) {
+	o, ok := ob.Orders[id]
+	if !ok || o.Limit == nil {
+		return 0, ErrOrderNotFound
+	}
+
+	position := 0
+	o.Limit.ForEachOrder(func(other *Order) bool {
+		if other == o {
+			return false
+		}
+		position++
+		return true
+	})
+	return position, nil
+}
 
-	for i := 0; i < len(ob.asks); i++ {
-		totalVolume += ob.asks[i].TotalVolume
+// The visible size resting ahead of the order with this id at its price
+// level, i.e. what has to trade before it starts filling. Iceberg reserves
+// don't count, a refreshed peak goes to the back of the queue.
+func (ob *Orderbook) QueueAheadVolume(id int64) (float64, error) {
+	o, ok := ob.Orders[id]
+	if !ok || o.Limit == nil {
+		return 0, ErrOrderNotFound
 	}
 
-	return totalVolume
+	ahead := 0.0
+	o.Limit.ForEachOrder(func(other *Order) bool {
+		if other == o {
+			return false
+		}
+		ahead += other.Size
+		return true
+	})
+	return ahead, nil
+}
+
+// How many price levels each side has
+func (ob *Orderbook) NumLevels() (askLevels, bidLevels int) {
+	return ob.levels(false).Len(), ob.levels(true).Len()
 }
 
+// How many orders are resting on the book, pending stops don't count
+func (ob *Orderbook) NumOrders() int {
+	return len(ob.Orders)
+}
+
+// Kept up to date as orders come and go, so this doesn't walk the levels
+func (ob *Orderbook) BidTotalVolume() float64 {
+	return ob.bidVolume
+}
+
+func (ob *Orderbook) AskTotalVolume() float64 {
+	return ob.askVolume
+}
+
+// Lowest price first. The slice is the caller's, but the levels are the
+// book's own, see AsksCopy for ones that are safe to change.
 func (ob *Orderbook) Asks() []*Limit {
-	sort.Sort(ByBestAsk{ob.asks}) // Doesn't return anything, just swaps in memory
-	return ob.asks
+	return ob.limits(false)
 }
 
+// Highest price first. The slice is the caller's, but the levels are the
+// book's own, see BidsCopy for ones that are safe to change.
 func (ob *Orderbook) Bids() []*Limit {
-	sort.Sort(ByBestBid{ob.bids}) // Doesn't return anything, just swaps in memory
-	return ob.bids
+	return ob.limits(true)
+}
+
+// Like Asks, but every level and order in it is a copy, so callers can sort,
+// edit or keep them around while the book moves on
+func (ob *Orderbook) AsksCopy() []*Limit {
+	return copyLimits(ob.limits(false))
+}
+
+// Like Bids, but every level and order in it is a copy
+func (ob *Orderbook) BidsCopy() []*Limit {
+	return copyLimits(ob.limits(true))
+}
+
+// Copies come out as standalone limits, not tied to any book
+func copyLimits(limits []*Limit) []*Limit {
+	copies := make([]*Limit, len(limits))
+	for i, l := range limits {
+		c := &Limit{
+			Price:       l.Price,
+			Orders:      make(Orders, len(l.Orders)),
+			TotalVolume: l.TotalVolume,
+			createdAt:   l.createdAt,
+		}
+		for j, o := range l.Orders {
+			order := *o
+			order.Limit = c
+			c.Orders[j] = &order
+		}
+		copies[i] = c
+	}
+	return copies
+}
+
+// The highest bid level, nil when there are no bids
+func (ob *Orderbook) BestBid() *Limit {
+	return ob.best(true)
+}
+
+// The lowest ask level, nil when there are no asks
+func (ob *Orderbook) BestAsk() *Limit {
+	return ob.best(false)
+}
+
+// The resting order an incoming buy (bid true) or sell would trade with first:
+// the oldest order at the best opposite price. Nothing changes on the book.
+// False when the opposite side is empty.
+func (ob *Orderbook) NextFill(bid bool) (*Order, bool) {
+	limit := ob.best(!bid)
+	if limit == nil {
+		return nil, false
+	}
+	return limit.Orders[0], true
+}
+
+// Halfway between the best bid and best ask, false if either side is empty
+func (ob *Orderbook) MidPrice() (float64, bool) {
+	bestBid, bestAsk := ob.BestBid(), ob.BestAsk()
+	if bestBid == nil || bestAsk == nil {
+		return 0.0, false
+	}
+	return (bestBid.Price + bestAsk.Price) / 2, true
+}
+
+// A price to show even when the book is one-sided. In order of preference:
+// the mid when both sides have orders, the last trade price, then the best
+// price of whichever side isn't empty. False only for an empty book that
+// never traded.
+func (ob *Orderbook) ReferencePrice() (float64, bool) {
+	if mid, ok := ob.MidPrice(); ok {
+		return mid, true
+	}
+	if last, ok := ob.LastPrice(); ok {
+		return last, true
+	}
+	if bestBid := ob.BestBid(); bestBid != nil {
+		return bestBid.Price, true
+	}
+	if bestAsk := ob.BestAsk(); bestAsk != nil {
+		return bestAsk.Price, true
+	}
+	return 0.0, false
+}
+
+// The mid weighted by the size at the top of each side, false if either side
+// is empty. A heavy bid pulls it toward the ask, since that's where the next
+// trade is more likely to happen.
+func (ob *Orderbook) Microprice() (float64, bool) {
+	bestBid, bestAsk := ob.BestBid(), ob.BestAsk()
+	if bestBid == nil || bestAsk == nil {
+		return 0.0, false
+	}
+
+	bidVol, askVol := bestBid.TotalVolume, bestAsk.TotalVolume
+	return (bestAsk.Price*bidVol + bestBid.Price*askVol) / (bidVol + askVol), true
+}
+
+// Whether the best bid is above the best ask, which a healthy book never allows
+func (ob *Orderbook) IsCrossed() bool {
+	bestBid, bestAsk := ob.BestBid(), ob.BestAsk()
+	return bestBid != nil && bestAsk != nil && bestBid.Price > bestAsk.Price
+}
+
+// Whether the best bid and best ask are at the same price. Only happens with
+// RestAtEqualPrice.
+func (ob *Orderbook) IsLocked() bool {
+	bestBid, bestAsk := ob.BestBid(), ob.BestAsk()
+	return bestBid != nil && bestAsk != nil && bestBid.Price == bestAsk.Price
+}
+
+// A readable ladder of the book for debugging, both sides best price first
+func (ob *Orderbook) String() string {
+	var sb strings.Builder
+
+	writeLevels := func(name string, limits []*Limit) {
+		fmt.Fprintf(&sb, "%s\n", name)
+		for _, l := range limits {
+			fmt.Fprintf(&sb, "  %10.2f  vol %10.2f  orders %d\n", l.Price, l.TotalVolume, len(l.Orders))
+		}
+	}
+	writeLevels("ASKS", ob.Asks())
+	writeLevels("BIDS", ob.Bids())
+
+	mid, ok := ob.MidPrice()
+	if !ok {
+		sb.WriteString("spread: -  mid: -\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "spread: %.2f  mid: %.2f\n", ob.BestAsk().Price-ob.BestBid().Price, mid)
+
+	return sb.String()
 }
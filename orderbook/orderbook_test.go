@@ -2,8 +2,12 @@ package orderbook
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
+	"time"
 )
 
 func assert(t *testing.T, a, b any) {
@@ -38,7 +42,7 @@ func TestPlaceLimitOrder(t *testing.T) {
 	assert(t, len(ob.Orders), 2)
 	assert(t, ob.Orders[sellOrderA.ID], sellOrderA)
 	assert(t, ob.Orders[sellOrderB.ID], sellOrderB)
-	assert(t, len(ob.asks), 2)
+	assert(t, len(ob.Asks()), 2)
 }
 
 func TestPlaceMarketOrder(t *testing.T) {
@@ -48,10 +52,10 @@ func TestPlaceMarketOrder(t *testing.T) {
 	ob.PlaceLimitOrder(10_000, sellOrder)
 
 	buyOrder := NewOrder(true, 10)
-	matches := ob.PlaceMarketOrder(buyOrder)
+	matches, _ := ob.PlaceMarketOrder(buyOrder)
 
 	assert(t, len(matches), 1)
-	assert(t, len(ob.asks), 1)
+	assert(t, len(ob.Asks()), 1)
 	assert(t, ob.AskTotalVolume(), 10.0)
 	assert(t, matches[0].Ask, sellOrder)
 	assert(t, matches[0].Bid, buyOrder)
@@ -76,11 +80,11 @@ func TestPlaceMarketOrderMultiFill(t *testing.T) {
 	assert(t, ob.BidTotalVolume(), 24.00)
 
 	sellOrder := NewOrder(false, 20)
-	matches := ob.PlaceMarketOrder(sellOrder)
+	matches, _ := ob.PlaceMarketOrder(sellOrder)
 
 	assert(t, ob.BidTotalVolume(), 4.0)
 	assert(t, len(matches), 3)
-	assert(t, len(ob.bids), 1)
+	assert(t, len(ob.Bids()), 1)
 }
 
 func TestPlaceLimitOrderMultiFill(t *testing.T) {
@@ -99,7 +103,7 @@ func TestPlaceLimitOrderMultiFill(t *testing.T) {
 
 	// Place a sell limit order
 	sellOrder := NewOrder(false, 10) // Sell 10 at 10,000
-	matches := ob.PlaceLimitOrder(9_000, sellOrder)
+	matches, _ := ob.PlaceLimitOrder(9_000, sellOrder)
 
 	// Check that the sell order was matched with all the buy orders
 	assert(t, len(matches), 2)            // There should be three matches
@@ -123,3 +127,739 @@ func TestCancelOrder(t *testing.T) {
 	_, ok := ob.Orders[buyOrder.ID]
 	assert(t, ok, false)
 }
+
+func TestSelfTradeSkip(t *testing.T) {
+	ob := NewOrderBook()
+	ob.STP = STPSkip
+
+	ownSell := NewOrder(false, 5, WithTraderID("alice"))
+	otherSell := NewOrder(false, 3, WithTraderID("bob"))
+	ob.PlaceLimitOrder(10_000, ownSell)
+	ob.PlaceLimitOrder(10_000, otherSell)
+
+	buyOrder := NewOrder(true, 5, WithTraderID("alice"))
+	matches, _ := ob.PlaceLimitOrder(10_000, buyOrder)
+
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Ask, otherSell)
+	assert(t, matches[0].SizeFilled, 3.0)
+	assert(t, ownSell.Size, 5.0)  // Own resting order is left untouched
+	assert(t, buyOrder.Size, 2.0) // Remainder rests on the book
+	assert(t, ob.AskTotalVolume(), 5.0)
+	assert(t, ob.BidTotalVolume(), 2.0)
+	assert(t, ob.Orders[ownSell.ID], ownSell)
+}
+
+func TestSelfTradeCancelResting(t *testing.T) {
+	ob := NewOrderBook()
+	ob.STP = STPCancelResting
+
+	ownSell := NewOrder(false, 5, WithTraderID("alice"))
+	ob.PlaceLimitOrder(10_000, ownSell)
+
+	buyOrder := NewOrder(true, 5, WithTraderID("alice"))
+	matches, _ := ob.PlaceLimitOrder(10_000, buyOrder)
+
+	assert(t, len(matches), 0)
+	assert(t, ob.AskTotalVolume(), 0.0)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, ob.BidTotalVolume(), 5.0)
+
+	_, ok := ob.Orders[ownSell.ID]
+	assert(t, ok, false)
+}
+
+func TestAnonymousOrdersStillMatch(t *testing.T) {
+	ob := NewOrderBook()
+
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 5))
+	matches, _ := ob.PlaceLimitOrder(10_000, NewOrder(true, 5))
+
+	assert(t, len(matches), 1)
+	assert(t, len(ob.Asks()), 0)
+}
+
+func TestOpenOrders(t *testing.T) {
+	ob := NewOrderBook()
+
+	aliceA := NewOrder(true, 5, WithTraderID("alice"))
+	aliceB := NewOrder(false, 2, WithTraderID("alice"))
+	bob := NewOrder(true, 3, WithTraderID("bob"))
+	ob.PlaceLimitOrder(9_000, aliceA)
+	ob.PlaceLimitOrder(11_000, aliceB)
+	ob.PlaceLimitOrder(9_500, bob)
+
+	assert(t, ob.OpenOrders("alice"), []*Order{aliceA, aliceB})
+	assert(t, ob.OpenOrders("bob"), []*Order{bob})
+	assert(t, len(ob.OpenOrders("carol")), 0)
+
+	ob.CancelOrder(aliceA)
+	assert(t, ob.OpenOrders("alice"), []*Order{aliceB})
+
+	// Filled orders drop out as well
+	ob.PlaceLimitOrder(9_500, NewOrder(false, 3, WithTraderID("carol")))
+	assert(t, len(ob.OpenOrders("bob")), 0)
+}
+
+func TestAllOrders(t *testing.T) {
+	ob := NewOrderBook()
+
+	ask2 := NewOrder(false, 1)
+	ask1 := NewOrder(false, 2)
+	ask3 := NewOrder(false, 3)
+	bid1 := NewOrder(true, 4)
+	bid2 := NewOrder(true, 5)
+	ob.PlaceLimitOrder(101, ask2)
+	ob.PlaceLimitOrder(100, ask1)
+	ob.PlaceLimitOrder(101, ask3)
+	ob.PlaceLimitOrder(98, bid2)
+	ob.PlaceLimitOrder(99, bid1)
+
+	want := []*Order{ask1, ask2, ask3, bid1, bid2}
+	for i := 0; i < 10; i++ {
+		assert(t, ob.AllOrders(), want)
+	}
+	assert(t, len(NewOrderBook().AllOrders()), 0)
+}
+
+func TestBookCopies(t *testing.T) {
+	ob := NewOrderBook()
+	ask := NewOrder(false, 1)
+	ob.PlaceLimitOrder(101, ask)
+	ob.PlaceLimitOrder(102, NewOrder(false, 2))
+	ob.PlaceLimitOrder(102, NewOrder(false, 3))
+	ob.PlaceLimitOrder(99, NewOrder(true, 4))
+	ob.PlaceLimitOrder(98, NewOrder(true, 5))
+
+	// Reordering the returned slices leaves the book alone
+	asks := ob.Asks()
+	sort.Sort(ByBestBid{asks})
+	assert(t, ob.BestAsk().Price, 101.0)
+
+	asksCopy := ob.AsksCopy()
+	bidsCopy := ob.BidsCopy()
+	assert(t, asksCopy[0].Price, 101.0)
+	assert(t, asksCopy[1].TotalVolume, 5.0)
+	assert(t, bidsCopy[0].Price, 99.0)
+	assert(t, asksCopy[0].Orders[0].ID, ask.ID)
+	assert(t, asksCopy[0].Orders[0].Limit, asksCopy[0])
+
+	// And so does changing the copied levels and orders
+	sort.Sort(ByBestBid{bidsCopy})
+	asksCopy[1].Orders[0], asksCopy[1].Orders[1] = asksCopy[1].Orders[1], asksCopy[1].Orders[0]
+	asksCopy[0].Orders[0].Size = 10
+	asksCopy[0].TotalVolume = 10
+
+	assert(t, ob.BestBid().Price, 99.0)
+	assert(t, ob.Asks()[1].Orders[0].Size, 2.0)
+	assert(t, ask.Size, 1.0)
+	assert(t, ob.AskTotalVolume(), 6.0)
+	assert(t, ob.Verify(), nil)
+}
+
+func TestNextFill(t *testing.T) {
+	ob := NewOrderBook()
+
+	_, ok := ob.NextFill(true)
+	assert(t, ok, false)
+
+	first := NewOrder(false, 1)
+	second := NewOrder(false, 2)
+	ob.PlaceLimitOrder(101, NewOrder(false, 3))
+	ob.PlaceLimitOrder(100, first)
+	ob.PlaceLimitOrder(100, second)
+	ob.PlaceLimitOrder(99, NewOrder(true, 4))
+	bid := NewOrder(true, 5)
+	ob.PlaceLimitOrder(99.5, bid)
+
+	next, ok := ob.NextFill(true)
+	assert(t, ok, true)
+	assert(t, next, first)
+	assert(t, ob.AskTotalVolume(), 6.0) // only a peek
+
+	next, _ = ob.NextFill(false)
+	assert(t, next, bid)
+
+	ob.PlaceMarketOrder(NewOrder(true, 1))
+	next, _ = ob.NextFill(true)
+	assert(t, next, second)
+}
+
+func TestCancelOrderByID(t *testing.T) {
+	ob := NewOrderBook()
+	resting := NewOrder(false, 10)
+	ob.PlaceLimitOrder(100, resting)
+	ob.PlaceMarketOrder(NewOrder(true, 4))
+
+	freed, err := ob.CancelOrderByID(resting.ID)
+	assert(t, err, nil)
+	assert(t, freed, 6.0)
+	assert(t, resting.Status, StatusCancelled)
+	assert(t, len(ob.Asks()), 0)
+
+	_, err = ob.CancelOrderByID(resting.ID)
+	assert(t, err, ErrOrderNotFound)
+
+	// an iceberg frees its reserve as well
+	iceberg := NewOrder(true, 10, WithDisplaySize(2))
+	ob.PlaceLimitOrder(99, iceberg)
+	freed, _ = ob.CancelOrderByID(iceberg.ID)
+	assert(t, freed, 10.0)
+}
+
+func TestReset(t *testing.T) {
+	ob := NewOrderBook()
+	fees := &FeeSchedule{Tiers: []FeeTier{{TakerRate: 0.001}}}
+	ob.STP = STPCancelResting
+	ob.Fees = fees
+
+	resting := NewOrder(false, 10, WithTraderID("alice"))
+	ob.PlaceLimitOrder(10_000, resting)
+	ob.PlaceLimitOrder(9_000, NewOrder(true, 4, WithTraderID("bob")))
+	ob.PlaceMarketOrder(NewOrder(true, 3, WithTraderID("bob")))
+
+	ob.Reset()
+
+	assert(t, len(ob.Orders), 0)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, len(ob.Bids()), 0)
+	assert(t, len(ob.AskLimits), 0)
+	assert(t, len(ob.BidLimits), 0)
+	assert(t, len(ob.Trades()), 0)
+	assert(t, len(ob.OpenOrders("alice")), 0)
+	assert(t, ob.AskTotalVolume(), 0.0)
+	assert(t, resting.Limit == nil, true)
+
+	assert(t, ob.STP, STPCancelResting)
+	assert(t, ob.Fees, fees)
+
+	// The book keeps working after a reset
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 1))
+	assert(t, ob.AskTotalVolume(), 1.0)
+}
+
+func TestClear(t *testing.T) {
+	ob := NewOrderBook()
+	ob.STP = STPCancelResting
+	ob.TickSize = 0.5
+	ob.Fees = &FeeSchedule{Tiers: []FeeTier{{TakerRate: 0.001}}}
+	ob.SetClock(func() time.Time { return time.Unix(0, 0) })
+
+	resting := NewOrder(false, 10, WithTraderID("alice"))
+	ob.PlaceLimitOrder(100, resting)
+	ob.PlaceLimitOrder(99, NewOrder(true, 4))
+	ob.PlaceMarketOrder(NewOrder(true, 3))
+	stop := NewOrder(true, 1, WithStopPrice(105))
+	ob.PlaceStopOrder(stop)
+
+	ob.Clear()
+
+	assert(t, len(ob.Orders), 0)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, len(ob.Bids()), 0)
+	assert(t, len(ob.AskLimits), 0)
+	assert(t, len(ob.BidLimits), 0)
+	assert(t, len(ob.Trades()), 0)
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, len(ob.OpenOrders("alice")), 0)
+	assert(t, ob.AskTotalVolume(), 0.0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+	assert(t, ob.BestAsk() == nil, true)
+	assert(t, ob.BestBid() == nil, true)
+	assert(t, resting.Limit == nil, true)
+
+	assert(t, ob.STP, STPCancelNewest)
+	assert(t, ob.TickSize, 0.0)
+	assert(t, ob.Fees == nil, true)
+	assert(t, ob.now().Unix() > 0, true)
+
+	ob.PlaceLimitOrder(100.25, NewOrder(false, 1))
+	assert(t, ob.AskTotalVolume(), 1.0)
+}
+
+func TestNumLevelsAndOrders(t *testing.T) {
+	ob := NewOrderBook()
+	asks, bids := ob.NumLevels()
+	assert(t, asks, 0)
+	assert(t, bids, 0)
+	assert(t, ob.NumOrders(), 0)
+
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(102, NewOrder(false, 1))
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	ob.PlaceStopOrder(NewOrder(true, 1, WithStopPrice(105)))
+
+	asks, bids = ob.NumLevels()
+	assert(t, asks, 2)
+	assert(t, bids, 1)
+	assert(t, ob.NumOrders(), 4)
+
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+	asks, _ = ob.NumLevels()
+	assert(t, asks, 1)
+	assert(t, ob.NumOrders(), 2)
+}
+
+func TestFillSkipsDegenerateOrders(t *testing.T) {
+	l := NewLimit(10_000)
+	stale := NewOrder(false, 5)
+	real := NewOrder(false, 3)
+	l.AddOrder(stale)
+	l.AddOrder(real)
+	stale.Size = 0 // filled but never taken off the level
+
+	matches := l.Fill(NewOrder(true, 2))
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Ask, real)
+	assert(t, matches[0].SizeFilled, 2.0)
+	assert(t, l.Orders, Orders{real})
+
+	// An ask can't fill an ask
+	matches = l.Fill(NewOrder(false, 1))
+	assert(t, len(matches), 0)
+	assert(t, real.Size, 1.0)
+}
+
+func TestMatchFilledFlags(t *testing.T) {
+	ob := NewOrderBook()
+
+	// Exact fill, both done
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	matches, _ := ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, matches[0].MakerFilled, true)
+	assert(t, matches[0].TakerFilled, true)
+
+	// Taker has size left after the first match
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	matches, _ = ob.PlaceLimitOrder(101, NewOrder(true, 2))
+	assert(t, matches[0].MakerFilled, true)
+	assert(t, matches[0].TakerFilled, false)
+	assert(t, matches[1].TakerFilled, true)
+
+	// Maker has size left
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+	matches, _ = ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, matches[0].MakerFilled, false)
+	assert(t, matches[0].TakerFilled, true)
+
+	// An iceberg's peak trading away doesn't finish it
+	ob.Reset()
+	ob.PlaceLimitOrder(100, NewOrder(false, 4, WithDisplaySize(2)))
+	matches, _ = ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, matches[0].MakerFilled, false)
+	matches, _ = ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, matches[0].MakerFilled, true)
+}
+
+func TestIsCrossedAndLocked(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, ob.IsCrossed(), false)
+	assert(t, ob.IsLocked(), false)
+
+	ob.RestAtEqualPrice = true
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	assert(t, ob.IsCrossed(), false)
+	assert(t, ob.IsLocked(), false)
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, ob.IsLocked(), true)
+	assert(t, ob.IsCrossed(), false)
+
+	// Matching never gets here, so put a level in by hand
+	l := NewLimit(101)
+	l.book = ob
+	ob.addLimit(true, l)
+	l.AddOrder(NewOrder(true, 1))
+	assert(t, ob.IsCrossed(), true)
+	assert(t, ob.IsLocked(), false)
+}
+
+func TestReferencePrice(t *testing.T) {
+	ob := NewOrderBook()
+	_, ok := ob.ReferencePrice()
+	assert(t, ok, false)
+
+	// One-sided and nothing traded, the best price there is
+	ob.PlaceLimitOrder(102, NewOrder(false, 2))
+	ref, ok := ob.ReferencePrice()
+	assert(t, ok, true)
+	assert(t, ref, 102.0)
+
+	// Two-sided, the mid
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	ref, _ = ob.ReferencePrice()
+	assert(t, ref, 101.0)
+
+	// One-sided again after a trade, the last price wins over the best ask
+	ob.PlaceMarketOrder(NewOrder(false, 1))
+	ref, _ = ob.ReferencePrice()
+	assert(t, ref, 100.0)
+	assert(t, ob.BestAsk().Price, 102.0)
+}
+
+func TestMicroprice(t *testing.T) {
+	ob := NewOrderBook()
+	_, ok := ob.Microprice()
+	assert(t, ok, false)
+
+	ob.PlaceLimitOrder(102, NewOrder(false, 1))
+	_, ok = ob.Microprice()
+	assert(t, ok, false)
+
+	// Even sizes, same as the mid
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	micro, ok := ob.Microprice()
+	assert(t, ok, true)
+	mid, _ := ob.MidPrice()
+	assert(t, micro, mid)
+
+	// Bids 3x the asks, leans toward the ask
+	ob.PlaceLimitOrder(100, NewOrder(true, 2))
+	micro, _ = ob.Microprice()
+	assert(t, micro, 101.5) // (102*3 + 100*1) / 4
+
+	// Asks 3x the bids, leans toward the bid
+	ob.PlaceLimitOrder(102, NewOrder(false, 8))
+	micro, _ = ob.Microprice()
+	assert(t, micro, 100.5) // (102*3 + 100*9) / 12
+
+	// Only the top level counts
+	ob.PlaceLimitOrder(99, NewOrder(true, 100))
+	micro, _ = ob.Microprice()
+	assert(t, micro, 100.5)
+}
+
+func TestForEachOrderAndQueuePosition(t *testing.T) {
+	ob := NewOrderBook()
+	a, b, c := NewOrder(false, 1), NewOrder(false, 2), NewOrder(false, 3)
+	ob.PlaceLimitOrder(100, a)
+	ob.PlaceLimitOrder(100, b)
+	ob.PlaceLimitOrder(100, c)
+
+	var seen []*Order
+	ob.AskLimits[100].ForEachOrder(func(o *Order) bool {
+		seen = append(seen, o)
+		return true
+	})
+	assert(t, seen, []*Order{a, b, c})
+
+	seen = nil
+	ob.AskLimits[100].ForEachOrder(func(o *Order) bool {
+		seen = append(seen, o)
+		return len(seen) < 2
+	})
+	assert(t, seen, []*Order{a, b})
+
+	pos, err := ob.QueuePosition(c.ID)
+	assert(t, err, nil)
+	assert(t, pos, 2)
+
+	ob.CancelOrder(a)
+	pos, _ = ob.QueuePosition(c.ID)
+	assert(t, pos, 1)
+	pos, _ = ob.QueuePosition(b.ID)
+	assert(t, pos, 0)
+
+	d := NewOrder(false, 1)
+	ob.PlaceLimitOrder(100, d)
+	pos, _ = ob.QueuePosition(d.ID)
+	assert(t, pos, 2)
+
+	_, err = ob.QueuePosition(a.ID)
+	assert(t, err, ErrOrderNotFound)
+}
+
+func TestQueueAheadVolume(t *testing.T) {
+	ob := NewOrderBook()
+	a, b, c := NewOrder(true, 1), NewOrder(true, 2, WithDisplaySize(1)), NewOrder(true, 3)
+	ob.PlaceLimitOrder(100, a)
+	ob.PlaceLimitOrder(100, b)
+	ob.PlaceLimitOrder(100, c)
+	ob.PlaceLimitOrder(101, NewOrder(true, 5)) // a better price isn't in the queue
+
+	ahead, err := ob.QueueAheadVolume(a.ID)
+	assert(t, err, nil)
+	assert(t, ahead, 0.0)
+	ahead, _ = ob.QueueAheadVolume(b.ID)
+	assert(t, ahead, 1.0)
+	ahead, _ = ob.QueueAheadVolume(c.ID)
+	assert(t, ahead, 2.0) // only b's peak shows
+
+	// 101 goes and half of a
+	ob.PlaceMarketOrder(NewOrder(false, 5.5))
+	ahead, _ = ob.QueueAheadVolume(c.ID)
+	assert(t, ahead, 1.5)
+
+	_, err = ob.QueueAheadVolume(12345)
+	assert(t, err, ErrOrderNotFound)
+}
+
+func TestPlaceOrderValidation(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 5))
+	ob.PlaceLimitOrder(9_000, NewOrder(true, 5))
+
+	sizes := []float64{0, -1, math.NaN(), math.Inf(1)}
+	for _, size := range sizes {
+		_, err := ob.PlaceLimitOrder(9_500, NewOrder(true, size))
+		assert(t, err, ErrInvalidSize)
+
+		_, err = ob.PlaceMarketOrder(NewOrder(true, size))
+		assert(t, err, ErrInvalidSize)
+	}
+
+	prices := []float64{0, -100, math.NaN(), math.Inf(1)}
+	for _, price := range prices {
+		_, err := ob.PlaceLimitOrder(price, NewOrder(false, 1))
+		assert(t, err, ErrInvalidPrice)
+	}
+
+	assert(t, len(ob.Orders), 2)
+	assert(t, len(ob.Asks()), 1)
+	assert(t, len(ob.Bids()), 1)
+	assert(t, ob.AskTotalVolume(), 5.0)
+	assert(t, ob.BidTotalVolume(), 5.0)
+}
+
+func TestQuoteCount(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, ob.QuoteCount("alice"), 0)
+
+	orderA := NewOrder(true, 5, WithTraderID("alice"))
+	orderB := NewOrder(true, 5, WithTraderID("alice"))
+	ob.PlaceLimitOrder(9_000, orderA)
+	ob.PlaceLimitOrder(9_100, orderB)
+	ob.PlaceLimitOrder(9_200, NewOrder(true, 5, WithTraderID("bob")))
+	assert(t, ob.QuoteCount("alice"), 2)
+
+	ob.CancelOrder(orderA)
+	assert(t, ob.QuoteCount("alice"), 1)
+
+	ob.CancelOrder(orderB)
+	assert(t, ob.QuoteCount("alice"), 0)
+	assert(t, ob.QuoteCount("bob"), 1)
+}
+
+func TestTickSize(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TickSize = 0.01
+
+	_, err := ob.PlaceLimitOrder(100.05, NewOrder(true, 1))
+	assert(t, err, nil)
+
+	_, err = ob.PlaceLimitOrder(100.005, NewOrder(true, 1))
+	assert(t, err, ErrInvalidTick)
+
+	assert(t, len(ob.Bids()), 1)
+	assert(t, ob.BidTotalVolume(), 1.0)
+
+	// No tick size means any price goes
+	ob.TickSize = 0
+	_, err = ob.PlaceLimitOrder(100.005, NewOrder(true, 1))
+	assert(t, err, nil)
+}
+
+func TestLotAndMinSize(t *testing.T) {
+	ob := NewOrderBook()
+	ob.LotSize = 0.1
+	ob.MinSize = 1.0
+
+	_, err := ob.PlaceLimitOrder(10_000, NewOrder(false, 1.3))
+	assert(t, err, nil)
+
+	_, err = ob.PlaceLimitOrder(10_000, NewOrder(false, 1.25))
+	assert(t, err, ErrInvalidLot)
+
+	_, err = ob.PlaceLimitOrder(10_000, NewOrder(false, 0.5))
+	assert(t, err, ErrBelowMinSize)
+
+	_, err = ob.PlaceMarketOrder(NewOrder(true, 0.7))
+	assert(t, err, ErrBelowMinSize)
+
+	_, err = ob.PlaceMarketOrder(NewOrder(true, 1.05))
+	assert(t, err, ErrInvalidLot)
+
+	_, err = ob.PlaceMarketOrder(NewOrder(true, 1.2))
+	assert(t, err, nil)
+
+	assert(t, len(ob.Orders), 1)
+}
+
+func TestEqualPriceIsMarketable(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 5))
+
+	matches, _ := ob.PlaceLimitOrder(10_000, NewOrder(true, 5))
+
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Price, 10_000.0)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, len(ob.Bids()), 0)
+}
+
+func TestRestAtEqualPrice(t *testing.T) {
+	ob := NewOrderBook()
+	ob.RestAtEqualPrice = true
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 5))
+
+	matches, _ := ob.PlaceLimitOrder(10_000, NewOrder(true, 5))
+
+	assert(t, len(matches), 0)
+	assert(t, ob.AskTotalVolume(), 5.0)
+	assert(t, ob.BidTotalVolume(), 5.0)
+
+	// Strictly better prices still trade
+	matches, _ = ob.PlaceLimitOrder(10_001, NewOrder(true, 2))
+	assert(t, len(matches), 1)
+}
+
+func TestIcebergOrder(t *testing.T) {
+	ob := NewOrderBook()
+
+	iceberg := NewOrder(false, 10, WithDisplaySize(2))
+	other := NewOrder(false, 3)
+	ob.PlaceLimitOrder(10_000, iceberg)
+	ob.PlaceLimitOrder(10_000, other)
+
+	assert(t, iceberg.Size, 2.0)
+	assert(t, iceberg.Hidden, 8.0)
+	assert(t, ob.AskTotalVolume(), 5.0)
+
+	// Taking the peak refreshes it behind the other order
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, iceberg.Size, 2.0)
+	assert(t, iceberg.Hidden, 6.0)
+	assert(t, ob.AskLimits[10_000].Orders, Orders{other, iceberg})
+	assert(t, ob.AskTotalVolume(), 5.0)
+
+	matches, _ := ob.PlaceMarketOrder(NewOrder(true, 4))
+	assert(t, len(matches), 2)
+	assert(t, matches[0].Ask, other)
+	assert(t, iceberg.Size, 1.0)
+	assert(t, ob.AskTotalVolume(), 1.0)
+
+	// One order can chew through several peaks
+	matches, _ = ob.PlaceLimitOrder(10_000, NewOrder(true, 7))
+	assert(t, len(matches), 4)
+	for _, m := range matches {
+		assert(t, m.Ask, iceberg)
+		assert(t, m.SizeFilled <= 2.0, true)
+	}
+	assert(t, iceberg.IsFilled(), true)
+	assert(t, iceberg.Hidden, 0.0)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, len(ob.Orders), 0)
+}
+
+func TestIncrementalVolumeTotals(t *testing.T) {
+	ob := NewOrderBook()
+	ob.STP = STPCancelResting
+
+	check := func() {
+		t.Helper()
+		var asks, bids float64
+		for _, l := range ob.Asks() {
+			asks += l.TotalVolume
+		}
+		for _, l := range ob.Bids() {
+			bids += l.TotalVolume
+		}
+		assert(t, math.Abs(ob.AskTotalVolume()-asks) < 1e-9, true)
+		assert(t, math.Abs(ob.BidTotalVolume()-bids) < 1e-9, true)
+	}
+
+	rng := rand.New(rand.NewSource(42))
+	var resting []*Order
+	for i := 0; i < 500; i++ {
+		bid := rng.Intn(2) == 0
+		size := float64(rng.Intn(5) + 1)
+
+		switch rng.Intn(5) {
+		case 0:
+			if len(resting) > 0 {
+				o := resting[rng.Intn(len(resting))]
+				if o.Limit != nil {
+					ob.CancelOrder(o)
+				}
+			}
+		case 1:
+			if (bid && ob.AskTotalVolume() >= size) || (!bid && ob.BidTotalVolume() >= size) {
+				ob.PlaceMarketOrder(NewOrder(bid, size))
+			}
+		case 2:
+			o := NewOrder(bid, size+3, WithDisplaySize(1), WithTraderID(fmt.Sprint(rng.Intn(3))))
+			ob.PlaceLimitOrder(float64(95+rng.Intn(10)), o)
+			resting = append(resting, o)
+		default:
+			o := NewOrder(bid, size, WithTraderID(fmt.Sprint(rng.Intn(3))))
+			ob.PlaceLimitOrder(float64(95+rng.Intn(10)), o)
+			resting = append(resting, o)
+		}
+		check()
+	}
+
+	ob.Reset()
+	assert(t, ob.AskTotalVolume(), 0.0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+}
+
+func TestOrderbookString(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, ob.String(), "ASKS\nBIDS\nspread: -  mid: -\n")
+
+	ob.PlaceLimitOrder(102, NewOrder(false, 1))
+	ob.PlaceLimitOrder(101, NewOrder(false, 2))
+	ob.PlaceLimitOrder(101, NewOrder(false, 3))
+	ob.PlaceLimitOrder(99, NewOrder(true, 4))
+	ob.PlaceLimitOrder(98.5, NewOrder(true, 5))
+
+	want := "ASKS\n" +
+		"      101.00  vol       5.00  orders 2\n" +
+		"      102.00  vol       1.00  orders 1\n" +
+		"BIDS\n" +
+		"       99.00  vol       4.00  orders 1\n" +
+		"       98.50  vol       5.00  orders 1\n" +
+		"spread: 2.00  mid: 100.00\n"
+	assert(t, ob.String(), want)
+	assert(t, ob.String(), want) // printing doesn't change anything
+}
+
+func TestSeededRunsAreReproducible(t *testing.T) {
+	type fill struct {
+		bid, ask    int64
+		size, price float64
+		ts          int64
+	}
+
+	run := func() []fill {
+		SeedOrderIDs(42)
+		ob := NewOrderBook()
+		ob.SetClock(func() time.Time { return time.Unix(1_700_000_000, 0) })
+
+		rng := rand.New(rand.NewSource(7))
+		var fills []fill
+		for i := 0; i < 200; i++ {
+			bid := rng.Intn(2) == 0
+			matches, _ := ob.PlaceLimitOrder(float64(95+rng.Intn(10)), NewOrder(bid, float64(1+rng.Intn(4))))
+			for _, m := range matches {
+				fills = append(fills, fill{m.Bid.ID, m.Ask.ID, m.SizeFilled, m.Price, m.Timestamp})
+			}
+		}
+		return fills
+	}
+
+	first := run()
+	assert(t, len(first) > 0, true)
+	assert(t, run(), first)
+}
+
+func TestNewOrderWithID(t *testing.T) {
+	o := NewOrderWithID(7, true, 3, WithTraderID("alice"))
+	assert(t, o.ID, int64(7))
+	assert(t, o.Bid, true)
+	assert(t, o.Size, 3.0)
+	assert(t, o.TraderID, "alice")
+}
@@ -0,0 +1,38 @@
+package orderbook
+
+type OrderEventType int
+
+const (
+	OrderAdded    OrderEventType = iota // the order went on the book
+	OrderModified                       // its size changed in place: a partial fill or an amend
+	OrderRemoved                        // it came off the book, filled (Size 0) or cancelled
+)
+
+// What happened to one resting order, the order level (L3) view of the book.
+// Size is what's left showing on the book after the change.
+type OrderEvent struct {
+	Type     OrderEventType
+	OrderID  int64
+	TraderID string
+	Bid      bool
+	Price    float64
+	Size     float64
+}
+
+// Puts an L3 event on the feed when OrderEvents is on. Fills publish the trade
+// first and then the event for the resting order, so the stream reads in the
+// same order things happened.
+func (ob *Orderbook) publishOrder(typ OrderEventType, o *Order, price float64) {
+	if !ob.OrderEvents {
+		return
+	}
+
+	ob.publish(FeedMessage{Type: FeedOrder, Order: OrderEvent{
+		Type:     typ,
+		OrderID:  o.ID,
+		TraderID: o.TraderID,
+		Bid:      o.Bid,
+		Price:    price,
+		Size:     o.Size,
+	}})
+}
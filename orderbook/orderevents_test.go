@@ -0,0 +1,70 @@
+package orderbook
+
+import "testing"
+
+// Just the L3 events and trades of a drained feed
+func orderEvents(msgs []FeedMessage) []FeedMessage {
+	var events []FeedMessage
+	for _, msg := range msgs {
+		if msg.Type != FeedDepth {
+			events = append(events, msg)
+		}
+	}
+	return events
+}
+
+func TestOrderEventsPlaceThenCancel(t *testing.T) {
+	ob := NewOrderBook()
+	ob.OrderEvents = true
+	feed := ob.Feed()
+
+	o := NewOrder(true, 5, WithTraderID("alice"))
+	ob.PlaceLimitOrder(99, o)
+	ob.CancelOrder(o)
+
+	msgs := drainFeed(feed)
+	assert(t, len(msgs), 4)
+	assert(t, msgs[0].Type, FeedOrder)
+	assert(t, msgs[0].Order, OrderEvent{Type: OrderAdded, OrderID: o.ID, TraderID: "alice", Bid: true, Price: 99, Size: 5})
+	assert(t, msgs[1].Type, FeedDepth)
+	assert(t, msgs[2].Type, FeedOrder)
+	assert(t, msgs[2].Order, OrderEvent{Type: OrderRemoved, OrderID: o.ID, TraderID: "alice", Bid: true, Price: 99, Size: 5})
+	assert(t, msgs[3].Type, FeedDepth)
+}
+
+func TestOrderEventsPlaceThenFill(t *testing.T) {
+	ob := NewOrderBook()
+	ob.OrderEvents = true
+
+	maker := NewOrder(false, 5)
+	ob.PlaceLimitOrder(100, maker)
+	feed := ob.Feed()
+
+	// a partial fill changes the maker in place, after the trade
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+	events := orderEvents(drainFeed(feed))
+	assert(t, len(events), 2)
+	assert(t, events[0].Type, FeedTrade)
+	assert(t, events[1].Order, OrderEvent{Type: OrderModified, OrderID: maker.ID, Price: 100, Size: 3})
+
+	// the rest of it fills, the maker comes off and the taker's remainder rests
+	taker := NewOrder(true, 4)
+	ob.PlaceLimitOrder(100, taker)
+	events = orderEvents(drainFeed(feed))
+	assert(t, len(events), 3)
+	assert(t, events[0].Type, FeedTrade)
+	assert(t, events[1].Order, OrderEvent{Type: OrderRemoved, OrderID: maker.ID, Price: 100, Size: 0})
+	assert(t, events[2].Order, OrderEvent{Type: OrderAdded, OrderID: taker.ID, Bid: true, Price: 100, Size: 1})
+
+	for i := 1; i < len(events); i++ {
+		assert(t, events[i].Seq > events[i-1].Seq, true)
+	}
+}
+
+func TestOrderEventsOffByDefault(t *testing.T) {
+	ob := NewOrderBook()
+	feed := ob.Feed()
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+	assert(t, len(orderEvents(drainFeed(feed))), 0)
+}
@@ -0,0 +1,31 @@
+package orderbook
+
+import "encoding/json"
+
+// JSON form of an order. The Limit back-pointer would loop forever (the
+// limit holds the order), so only the price of the limit goes out, and only
+// for a resting order.
+type orderJSON struct {
+	OrderSnapshot
+	Price float64 `json:",omitempty"`
+}
+
+func (o *Order) MarshalJSON() ([]byte, error) {
+	oj := orderJSON{OrderSnapshot: snapshotOrder(o)}
+	if o.Limit != nil {
+		oj.Price = o.Limit.Price
+	}
+	return json.Marshal(oj)
+}
+
+// Reads an order written by MarshalJSON. The order comes back off any book,
+// with Limit nil, a Price in the JSON is only there for the reader.
+func (o *Order) UnmarshalJSON(data []byte) error {
+	var oj orderJSON
+	if err := json.Unmarshal(data, &oj); err != nil {
+		return err
+	}
+
+	*o = *oj.order()
+	return nil
+}
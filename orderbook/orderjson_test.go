@@ -0,0 +1,46 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOrderJSONFresh(t *testing.T) {
+	o := NewOrder(true, 2.5, WithTraderID("alice"), WithDisplaySize(1))
+
+	data, err := json.Marshal(o)
+	assert(t, err, nil)
+	assert(t, strings.Contains(string(data), `"Price"`), false)
+
+	var back Order
+	assert(t, json.Unmarshal(data, &back), nil)
+	assert(t, &back, o)
+}
+
+func TestOrderJSONResting(t *testing.T) {
+	ob := NewOrderBook()
+	o := NewOrder(false, 5, WithTraderID("bob"))
+	ob.PlaceLimitOrder(101.5, o)
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+
+	data, err := json.Marshal(o)
+	assert(t, err, nil)
+
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	assert(t, fields["Price"], 101.5)
+	assert(t, fields["Size"], 3.0)
+	_, hasLimit := fields["Limit"]
+	assert(t, hasLimit, false)
+
+	var back Order
+	assert(t, json.Unmarshal(data, &back), nil)
+	assert(t, back.Limit == nil, true)
+	back.Limit = o.Limit
+	assert(t, &back, o)
+
+	// Matches carry orders, they marshal fine too
+	_, err = json.Marshal(ob.Trades())
+	assert(t, err, nil)
+}
@@ -0,0 +1,130 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrNoPegReference = errors.New("no reference price to peg the order to")
+
+// Reference price a pegged order follows
+type Peg int
+
+const (
+	PegNone Peg = iota
+	PegBid      // best bid
+	PegAsk      // best ask
+	PegMid      // halfway between best bid and best ask
+)
+
+func WithPeg(peg Peg, offset float64) OrderOption {
+	return func(o *Order) {
+		o.Peg = peg
+		o.PegOffset = offset
+	}
+}
+
+// Places a pegged order at its reference price plus offset. After that the
+// book moves it along whenever the reference changes, see Reprice.
+func (ob *Orderbook) PlacePeggedOrder(o *Order) ([]Match, error) {
+	price, ok := ob.pegPrice(o)
+	if !ok {
+		return nil, ErrNoPegReference
+	}
+
+	return ob.PlaceLimitOrder(price, o)
+}
+
+// Moves every pegged resting order whose reference price has changed to its new
+// price. A moved order goes to the back of its new limit and may trade if the
+// new price crosses. Placements call this already, it only needs calling by
+// hand after changing the book some other way. An order the book wouldn't
+// take at its new price (halted, outside the band, ...) stays where it is.
+// Nothing moves once the journal has failed.
+func (ob *Orderbook) Reprice() []Match {
+	if err := ob.journal(journalRecord{Op: opReprice}); err != nil {
+		return []Match{}
+	}
+
+	return ob.reprice()
+}
+
+func (ob *Orderbook) reprice() []Match {
+	matches := []Match{}
+
+	pegged := make([]*Order, len(ob.pegged))
+	copy(pegged, ob.pegged)
+
+	for _, o := range pegged {
+		if o.Limit == nil {
+			continue // traded away while repricing an earlier one
+		}
+
+		price, ok := ob.pegPrice(o)
+		if !ok || price == o.Limit.Price || validatePrice(price) != nil {
+			continue
+		}
+		if err := ob.admit(o, price, true); err != nil {
+			continue
+		}
+
+		ob.unrest(o)
+		o.Timestamp = ob.stamp()
+		matches = append(matches, ob.placeLimitOrder(price, o)...)
+	}
+
+	return matches
+}
+
+// Where a pegged order should sit right now. Levels that only hold pegged
+// orders don't count as a reference, otherwise pegged orders would follow
+// themselves.
+func (ob *Orderbook) pegPrice(o *Order) (float64, bool) {
+	bid, hasBid := ob.unpeggedBest(true)
+	ask, hasAsk := ob.unpeggedBest(false)
+
+	var ref float64
+	switch {
+	case o.Peg == PegBid && hasBid:
+		ref = bid
+	case o.Peg == PegAsk && hasAsk:
+		ref = ask
+	case o.Peg == PegMid && hasBid && hasAsk:
+		ref = (bid + ask) / 2
+	default:
+		return 0.0, false
+	}
+
+	price := ref + o.PegOffset
+	if ob.TickSize > 0 {
+		price = math.Round(price/ob.TickSize) * ob.TickSize
+	}
+
+	return price, true
+}
+
+func (ob *Orderbook) unpeggedBest(bid bool) (float64, bool) {
+	limits := ob.Asks()
+	if bid {
+		limits = ob.Bids()
+	}
+
+	for _, limit := range limits {
+		for _, order := range limit.Orders {
+			if order.Peg == PegNone {
+				return limit.Price, true
+			}
+		}
+	}
+
+	return 0.0, false
+}
+
+func (ob *Orderbook) removePegged(o *Order) {
+	for i := 0; i < len(ob.pegged); i++ {
+		if ob.pegged[i] == o {
+			ob.pegged = append(ob.pegged[:i], ob.pegged[i+1:]...)
+			return
+		}
+	}
+}
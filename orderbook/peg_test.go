@@ -0,0 +1,62 @@
+package orderbook
+
+import "testing"
+
+func TestPeggedOrderFollowsBestBid(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	ob.PlaceLimitOrder(110, NewOrder(false, 5))
+
+	pegged := NewOrder(true, 2, WithPeg(PegBid, -1))
+	_, err := ob.PlacePeggedOrder(pegged)
+	assert(t, err, nil)
+	assert(t, pegged.Limit.Price, 99.0)
+
+	// A better bid drags the pegged order up with it
+	ob.PlaceLimitOrder(102, NewOrder(true, 1))
+	assert(t, pegged.Limit.Price, 101.0)
+	assert(t, ob.BidLimits[101].Orders, Orders{pegged})
+	assert(t, len(ob.BidLimits), 3) // 102, 101 and 100; the old 99 level is gone
+	assert(t, ob.BidTotalVolume(), 8.0)
+
+	// and it follows the bid back down when that order goes away
+	ob.PlaceMarketOrder(NewOrder(false, 1))
+	assert(t, pegged.Limit.Price, 99.0)
+}
+
+func TestPeggedOrderMid(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	ob.PlaceLimitOrder(110, NewOrder(false, 5))
+
+	pegged := NewOrder(false, 1, WithPeg(PegMid, 0))
+	ob.PlacePeggedOrder(pegged)
+	assert(t, pegged.Limit.Price, 105.0)
+
+	ob.PlaceLimitOrder(108, NewOrder(false, 5))
+	assert(t, pegged.Limit.Price, 104.0)
+
+	mid, _ := ob.MidPrice()
+	assert(t, mid, 102.0) // the pegged order is the best ask now
+}
+
+func TestPeggedOrderWithoutReference(t *testing.T) {
+	ob := NewOrderBook()
+
+	_, err := ob.PlacePeggedOrder(NewOrder(true, 1, WithPeg(PegBid, 0)))
+	assert(t, err, ErrNoPegReference)
+	assert(t, len(ob.Orders), 0)
+}
+
+func TestPeggedOrderCancelled(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(true, 5))
+
+	pegged := NewOrder(true, 1, WithPeg(PegBid, 0))
+	ob.PlacePeggedOrder(pegged)
+	ob.CancelOrder(pegged)
+
+	assert(t, len(ob.pegged), 0)
+	ob.PlaceLimitOrder(101, NewOrder(true, 1))
+	assert(t, pegged.Limit == nil, true)
+}
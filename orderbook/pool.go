@@ -0,0 +1,44 @@
+package orderbook
+
+import (
+	"sync"
+	"time"
+)
+
+var orderPool = sync.Pool{
+	New: func() any { return new(Order) },
+}
+
+// Same as NewOrder, but reuses an order handed back with ReleaseOrder when
+// there is one, which takes a lot of pressure off the GC in busy books
+func AcquireOrder(bid bool, size float64, opts ...OrderOption) *Order {
+	o := orderPool.Get().(*Order)
+	o.ID = nextOrderID()
+	o.Size = size
+	o.Bid = bid
+	o.Timestamp = time.Now().UnixNano()
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Hands an order back for reuse. An order still resting on a book is
+// cancelled first so the book stops pointing at it, then every field is
+// cleared. Matches on the tape (and any you kept) still point at the order,
+// so copy what you need out of them before releasing. Pending stop orders
+// aren't on a limit yet, cancel those yourself first.
+func ReleaseOrder(o *Order) {
+	if l := o.Limit; l != nil {
+		if l.book != nil {
+			l.book.cancel(o)
+		} else {
+			l.DeleteOrder(o)
+		}
+	}
+
+	*o = Order{}
+	orderPool.Put(o)
+}
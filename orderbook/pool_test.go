@@ -0,0 +1,55 @@
+package orderbook
+
+import "testing"
+
+func TestAcquireOrder(t *testing.T) {
+	o := AcquireOrder(true, 5, WithTraderID("alice"), WithDisplaySize(1))
+	assert(t, o.Bid, true)
+	assert(t, o.Size, 5.0)
+	assert(t, o.TraderID, "alice")
+	assert(t, o.DisplaySize, 1.0)
+	assert(t, o.Limit == nil, true)
+}
+
+func TestReleaseOrderDetachesFromBook(t *testing.T) {
+	ob := NewOrderBook()
+	o := AcquireOrder(false, 5, WithTraderID("alice"))
+	ob.PlaceLimitOrder(100, o)
+	id := o.ID
+
+	ReleaseOrder(o)
+	_, ok := ob.Orders[id]
+	assert(t, ok, false)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, len(ob.OpenOrders("alice")), 0)
+	assert(t, ob.AskTotalVolume(), 0.0)
+	assert(t, *o, Order{})
+}
+
+func TestReleaseOrderDetachesFromLimit(t *testing.T) {
+	l := NewLimit(100)
+	o := AcquireOrder(true, 5)
+	l.AddOrder(o)
+
+	ReleaseOrder(o)
+	assert(t, len(l.Orders), 0)
+	assert(t, l.TotalVolume, 0.0)
+	assert(t, *o, Order{})
+}
+
+var sinkOrder *Order
+
+func BenchmarkNewOrder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sinkOrder = NewOrder(true, 1)
+	}
+}
+
+func BenchmarkAcquireOrder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sinkOrder = AcquireOrder(true, 1)
+		ReleaseOrder(sinkOrder)
+	}
+}
@@ -0,0 +1,28 @@
+package orderbook
+
+// What price a crossing order trades at
+type PriceImprovementMode int
+
+const (
+	PriceAtMaker PriceImprovementMode = iota // the resting order's price, the default
+	PriceAtMid                               // halfway between the resting order's price and the incoming order's limit
+	PriceAtTaker                             // the incoming order's limit, all the improvement goes to the maker
+)
+
+// The price the incoming order trades at on this level. Market orders have no
+// limit to improve towards, so they trade at the level's price. A protected
+// order's worst price is only a bound, it never sets the price it pays.
+// PriceAtMid can land between ticks.
+func (l *Limit) tradePrice(taker *Order) float64 {
+	if l.book == nil || taker.price <= 0 {
+		return l.Price
+	}
+
+	switch l.book.PriceImprovement {
+	case PriceAtMid:
+		return (l.Price + taker.price) / 2
+	case PriceAtTaker:
+		return taker.price
+	}
+	return l.Price
+}
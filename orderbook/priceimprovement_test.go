@@ -0,0 +1,46 @@
+package orderbook
+
+import "testing"
+
+func TestPriceImprovement(t *testing.T) {
+	for _, tc := range []struct {
+		mode  PriceImprovementMode
+		price float64
+	}{
+		{PriceAtMaker, 100},
+		{PriceAtMid, 101},
+		{PriceAtTaker, 102},
+	} {
+		ob := NewOrderBook()
+		ob.PriceImprovement = tc.mode
+		ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+		matches, _ := ob.PlaceLimitOrder(102, NewOrder(true, 2))
+		assert(t, len(matches), 1)
+		assert(t, matches[0].Price, tc.price)
+
+		// a sell crossing a bid improves the other way
+		ob.PlaceLimitOrder(96, NewOrder(true, 5))
+		matches, _ = ob.PlaceLimitOrder(94, NewOrder(false, 2))
+		assert(t, matches[0].Price, map[PriceImprovementMode]float64{
+			PriceAtMaker: 96, PriceAtMid: 95, PriceAtTaker: 94,
+		}[tc.mode])
+	}
+}
+
+func TestPriceImprovementMarketOrders(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PriceImprovement = PriceAtMid
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+	// nothing to improve towards
+	matches, _ := ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, matches[0].Price, 100.0)
+
+	// the worst price only bounds the order, it isn't a limit to improve towards
+	for _, mode := range []PriceImprovementMode{PriceAtMid, PriceAtTaker} {
+		ob.PriceImprovement = mode
+		matches, _ = ob.PlaceMarketOrder(NewOrder(true, 1, WithMaxPrice(110)))
+		assert(t, matches[0].Price, 100.0)
+	}
+}
@@ -0,0 +1,213 @@
+package orderbook
+
+import "math"
+
+// How a price level shares an incoming order out among its resting orders
+type MatchingMode int
+
+const (
+	FIFO            MatchingMode = iota // oldest order first, i.e. price-time priority
+	ProRata                             // split across the level in proportion to each order's size
+	ProRataTopOrder                     // the oldest order gets TopOrderAllocation first, the rest is split pro-rata
+)
+
+func (l *Limit) matchingMode() MatchingMode {
+	if l.book == nil {
+		return FIFO
+	}
+	return l.book.Matching
+}
+
+// The step pro-rata shares are cut in: the lot size, or without one the
+// book's rounding unit, so rounding a share doesn't leave bits of it over
+func (l *Limit) lotSize() float64 {
+	if l.book == nil {
+		return 0
+	}
+	if l.book.LotSize <= 0 && l.book.Rounding != nil {
+		return l.book.Rounding.unit()
+	}
+	return l.book.LotSize
+}
+
+// Pro-rata version of Fill. Every resting order (except our own) gets its
+// share of the incoming order by size, refreshed iceberg peaks get another go.
+// In ProRataTopOrder mode the oldest of them is served its guaranteed part first.
+func (l *Limit) fillProRata(o *Order) []Match {
+	var matches []Match
+
+	for !o.done() {
+		var (
+			eligible  []*Order
+			refreshed bool
+		)
+		// a copy, cancelled and filled orders come off l.Orders as we go
+		for _, order := range append([]*Order(nil), l.Orders...) {
+			if o.done() {
+				break
+			}
+
+			switch {
+			case isSelfTrade(order, o):
+				if l.preventSelfTrade(order, o) {
+					l.removeOrder(order)
+				}
+			case order.AON:
+				// can't take a share, it trades in full (oldest first) or sits this one out
+				if canTakeAll(o.Size, order) {
+					if match, ok := l.fillOrder(order, o); ok {
+						matches = append(matches, match)
+						l.addVolume(order.Bid, -match.SizeFilled)
+					}
+					if order.Hidden > 0 {
+						l.refreshPeak(order)
+						refreshed = true
+					} else {
+						l.removeOrder(order)
+					}
+				}
+			default:
+				eligible = append(eligible, order)
+			}
+		}
+		if len(eligible) == 0 || o.done() {
+			if refreshed && !o.done() {
+				continue
+			}
+			break
+		}
+
+		allocations := l.allocate(eligible, o.Size)
+		for i, order := range eligible {
+			if allocations[i] <= 0 {
+				continue
+			}
+
+			match, ok := l.fillOrderSize(order, o, allocations[i])
+			if ok {
+				matches = append(matches, match)
+				l.addVolume(order.Bid, -match.SizeFilled)
+			}
+		}
+
+		for _, order := range eligible {
+			if !order.IsFilled() {
+				continue
+			}
+			if order.Hidden > 0 {
+				l.refreshPeak(order)
+				refreshed = true
+				continue
+			}
+			l.removeOrder(order)
+		}
+
+		if !refreshed {
+			break
+		}
+	}
+
+	return matches
+}
+
+// How much of size each order gets, in the order's time priority
+func (l *Limit) allocate(orders []*Order, size float64) []float64 {
+	sizes := make([]float64, len(orders))
+	total := 0.0
+	for i, order := range orders {
+		sizes[i] = order.Size
+		total += order.Size
+	}
+
+	// The top order's guaranteed part, it still takes part in the pro-rata
+	// split of the rest with whatever it has left
+	top := 0.0
+	if l.matchingMode() == ProRataTopOrder {
+		top = math.Min(size*l.book.TopOrderAllocation, sizes[0])
+		if lot := l.lotSize(); lot > 0 {
+			top = math.Floor(top/lot+multipleEpsilon) * lot
+		}
+		sizes[0] -= top
+	}
+
+	allocations := l.proRataSplit(sizes, size-top)
+	allocations[0] += top
+
+	if size >= total {
+		return allocations
+	}
+
+	// The incoming order takes these off its size one at a time, so the last
+	// one gets exactly what is left after the others. That way it ends up at
+	// 0 and counts as filled instead of keeping a speck of float dust.
+	last, rest := -1, size
+	for i, a := range allocations {
+		if a <= 0 {
+			continue
+		}
+		if last >= 0 {
+			rest -= allocations[last]
+		}
+		last = i
+	}
+	if last >= 0 {
+		allocations[last] = rest
+	}
+
+	return allocations
+}
+
+// Splits size across orders of the given sizes by their share of the total.
+// With a lot size every share is rounded down to whole lots, the lots that
+// rounding leaves over go out one each in time priority. Without one the
+// last bit of float dust goes to the oldest order with room for it.
+func (l *Limit) proRataSplit(sizes []float64, size float64) []float64 {
+	allocations := make([]float64, len(sizes))
+
+	total := 0.0
+	for _, s := range sizes {
+		total += s
+	}
+	if size >= total {
+		copy(allocations, sizes)
+		return allocations
+	}
+
+	lot := l.lotSize()
+	left := size
+	for i, s := range sizes {
+		share := size * s / total
+		if lot > 0 {
+			share = math.Floor(share/lot+multipleEpsilon) * lot
+		}
+		allocations[i] = share
+		left -= share
+	}
+	if lot > 0 {
+		left = math.Round(left/lot) * lot
+	}
+
+	for i, s := range sizes {
+		if left <= 0 {
+			break
+		}
+
+		extra := math.Min(left, s-allocations[i])
+		if lot > 0 {
+			extra = math.Min(extra, lot)
+		}
+		allocations[i] += extra
+		left -= extra
+	}
+
+	return allocations
+}
+
+// Takes an order that is done trading off the level and out of the book's lookups
+func (l *Limit) removeOrder(o *Order) {
+	l.DeleteOrder(o)
+
+	if l.book != nil {
+		l.book.untrackOrder(o)
+	}
+}
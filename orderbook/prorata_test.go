@@ -0,0 +1,118 @@
+package orderbook
+
+import "testing"
+
+// Three resting asks of 6, 3 and 1 at the same price and a buy for 5
+func proRataBook(mode MatchingMode) (*Orderbook, []*Order) {
+	ob := NewOrderBook()
+	ob.Matching = mode
+	resting := []*Order{NewOrder(false, 6), NewOrder(false, 3), NewOrder(false, 1)}
+	for _, o := range resting {
+		ob.PlaceLimitOrder(100, o)
+	}
+	return ob, resting
+}
+
+func TestFIFOvsProRata(t *testing.T) {
+	ob, resting := proRataBook(FIFO)
+	matches, _ := ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	assert(t, len(matches), 1)
+	assert(t, resting[0].Size, 1.0)
+	assert(t, resting[1].Size, 3.0)
+	assert(t, resting[2].Size, 1.0)
+
+	ob, resting = proRataBook(ProRata)
+	taker := NewOrder(true, 5)
+	matches, _ = ob.PlaceLimitOrder(100, taker)
+	assert(t, len(matches), 3)
+	assert(t, matches[0].SizeFilled, 3.0)
+	assert(t, matches[1].SizeFilled, 1.5)
+	assert(t, matches[2].SizeFilled, 0.5)
+	assert(t, resting[0].Size, 3.0)
+	assert(t, resting[1].Size, 1.5)
+	assert(t, resting[2].Size, 0.5)
+	assert(t, taker.IsFilled(), true)
+	assert(t, ob.AskTotalVolume(), 5.0)
+}
+
+func TestProRataRoundsToLots(t *testing.T) {
+	ob, resting := proRataBook(ProRata)
+	ob.LotSize = 1
+
+	// 3, 1.5 and 0.5 round down to 3, 1 and 0, the lot left over goes to the oldest order
+	matches, _ := ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	assert(t, len(matches), 2)
+	assert(t, resting[0].Size, 2.0)
+	assert(t, resting[1].Size, 2.0)
+	assert(t, resting[2].Size, 1.0)
+	assert(t, ob.AskTotalVolume(), 5.0)
+}
+
+func TestProRataTakesEverything(t *testing.T) {
+	ob, resting := proRataBook(ProRata)
+	taker := NewOrder(true, 12)
+	matches, _ := ob.PlaceLimitOrder(100, taker)
+
+	assert(t, len(matches), 3)
+	for _, o := range resting {
+		assert(t, o.Status, StatusFilled)
+	}
+	assert(t, taker.Size, 2.0)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, ob.BidTotalVolume(), 2.0) // the rest rests
+}
+
+func TestProRataFillsExactly(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Matching = ProRata
+	for _, size := range []float64{0.7, 0.3, 1.1, 0.9} {
+		ob.PlaceLimitOrder(100, NewOrder(false, size))
+	}
+
+	for i := 0; i < 5; i++ {
+		taker := NewOrder(true, 0.1)
+		ob.PlaceLimitOrder(100, taker)
+		assert(t, taker.IsFilled(), true)
+	}
+	assert(t, len(ob.BidLimits), 0)
+}
+
+func TestProRataTopOrder(t *testing.T) {
+	ob, resting := proRataBook(ProRataTopOrder)
+	ob.TopOrderAllocation = 0.4
+
+	// The top order is guaranteed 40% of 5 = 2. The other 3 is split over
+	// 4, 3 and 1 (the top order counting with what it has left).
+	matches, _ := ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	assert(t, len(matches), 3)
+	assert(t, matches[0].SizeFilled, 2+3*4.0/8)
+	assert(t, matches[1].SizeFilled, 3*3.0/8)
+	assert(t, matches[2].SizeFilled, 3*1.0/8)
+	assert(t, resting[0].Size, 2.5)
+	assert(t, ob.AskTotalVolume(), 5.0)
+}
+
+func TestProRataTopOrderCappedBySize(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Matching = ProRataTopOrder
+	ob.TopOrderAllocation = 0.5
+	ob.LotSize = 1
+	top, big := NewOrder(false, 1), NewOrder(false, 9)
+	ob.PlaceLimitOrder(100, top)
+	ob.PlaceLimitOrder(100, big)
+
+	// Half of 4 would be 2 but the top order only has 1, the other 3 go pro-rata to big
+	ob.PlaceLimitOrder(100, NewOrder(true, 4))
+	assert(t, top.Status, StatusFilled)
+	assert(t, big.Size, 6.0)
+}
+
+func TestProRataTopOrderAllOfIt(t *testing.T) {
+	ob, resting := proRataBook(ProRataTopOrder)
+	ob.TopOrderAllocation = 1
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	assert(t, resting[0].Size, 1.0)
+	assert(t, resting[1].Size, 3.0)
+	assert(t, resting[2].Size, 1.0)
+}
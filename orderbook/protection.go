@@ -0,0 +1,58 @@
+package orderbook
+
+// Stops a buy market order from filling above maxPrice
+func WithMaxPrice(maxPrice float64) OrderOption {
+	return func(o *Order) {
+		o.MaxPrice = maxPrice
+	}
+}
+
+// Stops a sell market order from filling below minPrice
+func WithMinPrice(minPrice float64) OrderOption {
+	return func(o *Order) {
+		o.MinPrice = minPrice
+	}
+}
+
+// Makes a market order with a protection price a market-to-limit order, see
+// PlaceMarketToLimitOrder
+func WithRestRemainder() OrderOption {
+	return func(o *Order) {
+		o.RestRemainder = true
+	}
+}
+
+// Fills at market as far as the order's protection price (MaxPrice for buys,
+// MinPrice for sells) allows and rests the rest there as a limit order. Returns
+// the matches and the id of the resting remainder, 0 when it filled in full.
+// Without a protection price it's rejected with ErrInvalidPrice.
+func (ob *Orderbook) PlaceMarketToLimitOrder(o *Order) (matches []Match, restingID int64, err error) {
+	o.RestRemainder = true
+	if matches, err = ob.PlaceMarketOrder(o); err != nil {
+		return nil, 0, err
+	}
+	if o.Limit != nil {
+		restingID = o.ID
+	}
+	return matches, restingID, nil
+}
+
+// Whether a market order may still trade at price. Once it can't, whatever is
+// left of the order is dropped and its Size says how much went unfilled.
+func (o *Order) withinProtection(price float64) bool {
+	if o.Bid && o.MaxPrice > 0 {
+		return price <= o.MaxPrice
+	}
+	if !o.Bid && o.MinPrice > 0 {
+		return price >= o.MinPrice
+	}
+	return true
+}
+
+// The worst price the order accepts on its side, 0 when it isn't protected
+func (o *Order) protectionPrice() float64 {
+	if o.Bid {
+		return o.MaxPrice
+	}
+	return o.MinPrice
+}
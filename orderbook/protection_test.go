@@ -0,0 +1,89 @@
+package orderbook
+
+import "testing"
+
+func TestMarketOrderMaxPrice(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	ob.PlaceLimitOrder(101, NewOrder(false, 2))
+	ob.PlaceLimitOrder(105, NewOrder(false, 2))
+
+	buy := NewOrder(true, 5, WithMaxPrice(101))
+	matches, err := ob.PlaceMarketOrder(buy)
+	assert(t, err, nil)
+	assert(t, len(matches), 2)
+	assert(t, matches[1].Price, 101.0)
+	assert(t, buy.Size, 1.0) // unfilled and dropped
+	assert(t, ob.BestAsk().Price, 105.0)
+	assert(t, ob.NumOrders(), 1)
+}
+
+func TestMarketOrderMinPrice(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(true, 2))
+	ob.PlaceLimitOrder(90, NewOrder(true, 2))
+
+	// More than the whole book, protection stops it before it would panic
+	sell := NewOrder(false, 10, WithMinPrice(95))
+	matches, err := ob.PlaceMarketOrder(sell)
+	assert(t, err, nil)
+	assert(t, len(matches), 1)
+	assert(t, sell.Size, 8.0)
+	assert(t, ob.BestBid().Price, 90.0)
+}
+
+func TestMarketOrderProtectionOtherSideIgnored(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+
+	// MinPrice means nothing to a buy
+	buy := NewOrder(true, 2, WithMinPrice(1000))
+	matches, _ := ob.PlaceMarketOrder(buy)
+	assert(t, len(matches), 1)
+	assert(t, buy.IsFilled(), true)
+}
+
+func TestMarketToLimitFullyFilled(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	ob.PlaceLimitOrder(101, NewOrder(false, 2))
+
+	buy := NewOrder(true, 3, WithMaxPrice(101))
+	matches, restingID, err := ob.PlaceMarketToLimitOrder(buy)
+	assert(t, err, nil)
+	assert(t, len(matches), 2)
+	assert(t, restingID, int64(0))
+	assert(t, buy.IsFilled(), true)
+	assert(t, len(ob.Bids()), 0)
+}
+
+func TestMarketToLimitRestsAtCap(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	ob.PlaceLimitOrder(105, NewOrder(false, 2))
+
+	buy := NewOrder(true, 5, WithMaxPrice(101))
+	matches, restingID, err := ob.PlaceMarketToLimitOrder(buy)
+	assert(t, err, nil)
+	assert(t, len(matches), 1)
+	assert(t, restingID, buy.ID)
+	assert(t, ob.ToSpec(), "S 2 @ 105\nB 3 @ 101\n")
+
+	// A sell market order with WithRestRemainder works the same way
+	sell := NewOrder(false, 4, WithMinPrice(101), WithRestRemainder())
+	matches, _ = ob.PlaceMarketOrder(sell)
+	assert(t, len(matches), 1)
+	assert(t, ob.ToSpec(), "S 2 @ 105\nS 1 @ 101\n")
+}
+
+func TestMarketToLimitRejected(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TickSize = 1
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+
+	_, _, err := ob.PlaceMarketToLimitOrder(NewOrder(true, 1))
+	assert(t, err, ErrInvalidPrice) // no cap to rest at
+	_, _, err = ob.PlaceMarketToLimitOrder(NewOrder(true, 1, WithMaxPrice(100.5)))
+	assert(t, err, ErrInvalidTick)
+	assert(t, ob.AskTotalVolume(), 2.0)
+}
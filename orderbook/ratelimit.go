@@ -0,0 +1,74 @@
+package orderbook
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("trader is sending requests faster than the rate limit")
+
+// Decides whether a trader may send another request at now. Called once per
+// placement or cancel, so an implementation that allows it should also count it.
+type RateLimiter interface {
+	Allow(traderID string, now time.Time) bool
+}
+
+// A token bucket per trader: each request takes a token, buckets hold up to
+// Burst tokens and refill at Rate tokens per second. Safe to share between
+// books, e.g. to limit a trader across every market of an exchange.
+type TokenBucket struct {
+	Rate  float64
+	Burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate, burst float64) *TokenBucket {
+	return &TokenBucket{
+		Rate:    rate,
+		Burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (tb *TokenBucket) Allow(traderID string, now time.Time) bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	b, ok := tb.buckets[traderID]
+	if !ok {
+		b = &bucket{tokens: tb.Burst, last: now}
+		tb.buckets[traderID] = b
+	}
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(tb.Burst, b.tokens+elapsed*tb.Rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Checks the trader behind o against the book's rate limiter. Anonymous
+// orders aren't tied to anyone, so they're never limited.
+func (ob *Orderbook) allow(o *Order) error {
+	if ob.RateLimiter == nil || o.TraderID == "" {
+		return nil
+	}
+	if !ob.RateLimiter.Allow(o.TraderID, ob.now()) {
+		ob.debug("rate limited", "id", o.ID, "trader", o.TraderID)
+		return ErrRateLimited
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ob := NewOrderBook()
+	now := time.Unix(1_700_000_000, 0)
+	ob.SetClock(func() time.Time { return now })
+	ob.RateLimiter = NewTokenBucket(1, 3) // 1 request a second, bursts of 3
+
+	for i := 0; i < 3; i++ {
+		_, err := ob.PlaceLimitOrder(100, NewOrder(true, 1, WithTraderID("alice")))
+		assert(t, err, nil)
+	}
+
+	// Bucket's empty, nothing changes on the book
+	_, err := ob.PlaceLimitOrder(100, NewOrder(true, 1, WithTraderID("alice")))
+	assert(t, errors.Is(err, ErrRateLimited), true)
+	_, err = ob.PlaceMarketOrder(NewOrder(false, 1, WithTraderID("alice")))
+	assert(t, errors.Is(err, ErrRateLimited), true)
+	resting := ob.OpenOrders("alice")[2] // bob trades with the oldest below
+	assert(t, errors.Is(ob.CancelOrder(resting), ErrRateLimited), true)
+	assert(t, ob.BidTotalVolume(), 3.0)
+	assert(t, resting.Status, StatusNew)
+
+	// Other traders and anonymous orders have their own budget
+	_, err = ob.PlaceMarketOrder(NewOrder(false, 1, WithTraderID("bob")))
+	assert(t, err, nil)
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, err, nil)
+
+	// One token back after a second
+	now = now.Add(time.Second)
+	assert(t, ob.CancelOrder(resting), nil)
+	assert(t, resting.Status, StatusCancelled)
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1, WithTraderID("alice")))
+	assert(t, errors.Is(err, ErrRateLimited), true)
+
+	// Refills stop at the burst size
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1, WithTraderID("alice")))
+		assert(t, err, nil)
+	}
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1, WithTraderID("alice")))
+	assert(t, errors.Is(err, ErrRateLimited), true)
+}
+
+func TestRateLimiterSkipsBookCancels(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetClock(func() time.Time { return time.Unix(0, 0) })
+	ob.RateLimiter = NewTokenBucket(0, 2)
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 1, WithTraderID("alice")))
+	ob.PlaceLimitOrder(101, NewOrder(true, 1, WithTraderID("alice")))
+
+	ids := ob.CancelAll(CancelAllOptions{TraderID: "alice"})
+	assert(t, len(ids), 2)
+	assert(t, ob.NumOrders(), 0)
+}
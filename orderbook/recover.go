@@ -0,0 +1,22 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrPanic = errors.New("matching engine panicked")
+
+// Deferred by the order entry points so a panic while placing one order comes
+// back as an error instead of taking down every market in the process. A
+// panic in the middle of matching can leave a partial fill behind, restore a
+// snapshot if that matters.
+func (ob *Orderbook) recoverPanic(op string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	*err = fmt.Errorf("%w: %v", ErrPanic, r)
+	ob.logError("recovered from panic", "op", op, "panic", r)
+}
@@ -0,0 +1,54 @@
+package orderbook
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// Panics for one trader, e.g. a bug in the caller's account lookup
+type panickyAccounts struct{}
+
+func (panickyAccounts) CanPlace(traderID string, o *Order, price float64) error {
+	if traderID == "bob" {
+		panic("no account for bob")
+	}
+	return nil
+}
+
+func TestPanicBecomesError(t *testing.T) {
+	var msgs []string
+	ob := NewOrderBook()
+	ob.Accounts = panickyAccounts{}
+	ob.SetLogger(slog.New(captureHandler{&msgs}))
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	msgs = nil
+
+	matches, err := ob.PlaceMarketOrder(NewOrder(true, 1, WithTraderID("bob")))
+
+	assert(t, errors.Is(err, ErrPanic), true)
+	assert(t, len(matches), 0)
+	assert(t, msgs, []string{"recovered from panic"})
+
+	// the book is untouched and keeps working
+	assert(t, ob.AskTotalVolume(), 2.0)
+	matches, err = ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, err, nil)
+	assert(t, len(matches), 1)
+}
+
+func TestMarketOrderTooLargeIsAnError(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+
+	matches, err := ob.PlaceMarketOrder(NewOrder(true, 5))
+	assert(t, err, ErrNotEnoughVolume)
+	assert(t, len(matches), 0)
+	assert(t, ob.AskTotalVolume(), 2.0)
+
+	// an iceberg's reserve counts
+	ob.PlaceLimitOrder(101, NewOrder(false, 10, WithDisplaySize(2)))
+	matches, err = ob.PlaceMarketOrder(NewOrder(true, 5))
+	assert(t, err, nil)
+	assert(t, len(matches) > 0, true)
+}
@@ -0,0 +1,23 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrReduceOnly = errors.New("reduce-only order would not reduce the position")
+
+// Places a reduce-only limit order. The book doesn't know about positions so the
+// caller passes the trader's current one (positive is long, negative is short).
+// The order has to be on the opposite side of the position and its size is
+// capped at the position size so it can never flip it.
+func (ob *Orderbook) PlaceReduceOnly(price float64, o *Order, position float64) ([]Match, error) {
+	if position == 0 || (position > 0) == o.Bid {
+		return nil, ErrReduceOnly
+	}
+
+	o.ReduceOnly = true
+	o.Size = math.Min(o.Size, math.Abs(position))
+
+	return ob.PlaceLimitOrder(price, o)
+}
@@ -0,0 +1,42 @@
+package orderbook
+
+import "testing"
+
+func TestReduceOnlyClamped(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(true, 10))
+
+	// Long 4, so a sell of 6 may only sell 4
+	sellOrder := NewOrder(false, 6)
+	matches, err := ob.PlaceReduceOnly(10_000, sellOrder, 4)
+
+	assert(t, err, nil)
+	assert(t, sellOrder.ReduceOnly, true)
+	assert(t, len(matches), 1)
+	assert(t, matches[0].SizeFilled, 4.0)
+	assert(t, ob.BidTotalVolume(), 6.0)
+	assert(t, len(ob.Asks()), 0)
+}
+
+func TestReduceOnlyShortPosition(t *testing.T) {
+	ob := NewOrderBook()
+
+	buyOrder := NewOrder(true, 5)
+	_, err := ob.PlaceReduceOnly(9_000, buyOrder, -2)
+
+	assert(t, err, nil)
+	assert(t, ob.BidTotalVolume(), 2.0)
+}
+
+func TestReduceOnlyRejected(t *testing.T) {
+	ob := NewOrderBook()
+
+	_, err := ob.PlaceReduceOnly(10_000, NewOrder(false, 1), 0)
+	assert(t, err, ErrReduceOnly)
+
+	// Buying while long would grow the position
+	_, err = ob.PlaceReduceOnly(10_000, NewOrder(true, 1), 3)
+	assert(t, err, ErrReduceOnly)
+
+	assert(t, len(ob.Orders), 0)
+}
@@ -0,0 +1,64 @@
+package orderbook
+
+import "math"
+
+// How a fill's price and size are rounded to the book's decimal places
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // 0.125 -> 0.13, the default
+	RoundDown                         // towards zero, 0.129 -> 0.12
+	RoundHalfEven                     // bankers' rounding, 0.125 -> 0.12 and 0.135 -> 0.14
+)
+
+// Rounds every fill's Price and SizeFilled to Places decimals
+type Rounding struct {
+	Places int
+	Mode   RoundingMode
+}
+
+// The smallest step a rounded value can take, e.g. 0.01 for 2 places
+func (r *Rounding) unit() float64 {
+	return math.Pow10(-r.Places)
+}
+
+func (r *Rounding) round(x float64) float64 {
+	scale := math.Pow10(r.Places)
+	v := x * scale
+
+	// 0.145 * 100 is 14.499999999999998, it should count as the tie it is
+	if half := math.Round(v*2) / 2; math.Abs(v-half) < 1e-9 {
+		v = half
+	}
+
+	switch r.Mode {
+	case RoundDown:
+		v = math.Trunc(v)
+	case RoundHalfEven:
+		v = math.RoundToEven(v)
+	default:
+		v = math.Floor(v + 0.5)
+	}
+	return v / scale
+}
+
+// Rounds a fill of size between two orders of which the smaller has room
+// left. Rounding never takes more than that, and when it would leave less
+// than a unit behind, that bit goes in the fill too instead of being stranded
+// on an order nothing can trade it against.
+func (r *Rounding) fillSize(size, room float64) float64 {
+	size = math.Min(r.round(size), room)
+	if room-size < r.unit()-1e-9 {
+		return room
+	}
+	return size
+}
+
+// Rounds a fill's size and price, if the book rounds at all
+func (l *Limit) roundFill(a, b *Order, size, price float64) (float64, float64) {
+	if l.book == nil || l.book.Rounding == nil {
+		return size, price
+	}
+	r := l.book.Rounding
+	return r.fillSize(size, math.Min(a.Size, b.Size)), r.round(price)
+}
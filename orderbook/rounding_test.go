@@ -0,0 +1,82 @@
+package orderbook
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		x                    float64
+		halfUp, down, halfEv float64
+	}{
+		{0.125, 0.13, 0.12, 0.12},
+		{0.135, 0.14, 0.13, 0.14},
+		{0.145, 0.15, 0.14, 0.14}, // 14.499999999999998 before the tie is spotted
+		{0.129, 0.13, 0.12, 0.13},
+		{2, 2, 2, 2},
+	}
+	for _, tt := range tests {
+		assert(t, (&Rounding{Places: 2, Mode: RoundHalfUp}).round(tt.x), tt.halfUp)
+		assert(t, (&Rounding{Places: 2, Mode: RoundDown}).round(tt.x), tt.down)
+		assert(t, (&Rounding{Places: 2, Mode: RoundHalfEven}).round(tt.x), tt.halfEv)
+	}
+}
+
+func TestRoundingModesOnMidPrice(t *testing.T) {
+	for mode, want := range map[RoundingMode]float64{
+		RoundHalfUp:   100.03,
+		RoundDown:     100.02,
+		RoundHalfEven: 100.02,
+	} {
+		ob := NewOrderBook()
+		ob.PriceImprovement = PriceAtMid
+		ob.Rounding = &Rounding{Places: 2, Mode: mode}
+		ob.PlaceLimitOrder(100.02, NewOrder(false, 1))
+
+		// Halfway between 100.02 and 100.03 doesn't fit in 2 places
+		matches, _ := ob.PlaceLimitOrder(100.03, NewOrder(true, 1))
+		assert(t, len(matches), 1)
+		assert(t, matches[0].Price, want)
+	}
+}
+
+func TestRoundingProRataKeepsVolume(t *testing.T) {
+	for _, mode := range []RoundingMode{RoundHalfUp, RoundDown, RoundHalfEven} {
+		ob := NewOrderBook()
+		ob.Matching = ProRata
+		ob.Rounding = &Rounding{Places: 2, Mode: mode}
+		first := NewOrder(false, 1)
+		ob.PlaceLimitOrder(100, first)
+		ob.PlaceLimitOrder(100, NewOrder(false, 1))
+		ob.PlaceLimitOrder(100, NewOrder(false, 1))
+
+		// A third each doesn't fit in 2 places, the oldest gets the cent left over
+		buy := NewOrder(true, 1)
+		matches, _ := ob.PlaceMarketOrder(buy)
+		assert(t, len(matches), 3)
+		assert(t, matches[0].SizeFilled, 0.34)
+		assert(t, matches[1].SizeFilled, 0.33)
+		assert(t, math.Abs(matches[2].SizeFilled-0.33) < 1e-9, true) // what's left of the buy
+		assert(t, buy.IsFilled(), true)
+		assert(t, math.Abs(first.Size-0.66) < 1e-9, true)
+		assert(t, math.Abs(ob.AskTotalVolume()-2) < 1e-9, true)
+		assert(t, ob.Verify(), nil)
+	}
+}
+
+func TestRoundingDoesNotStrandResiduals(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Rounding = &Rounding{Places: 2, Mode: RoundDown}
+	ob.PlaceLimitOrder(100, NewOrder(false, 1.234))
+
+	// 1.23 would leave 0.004 that could never trade, so it all goes
+	matches, _ := ob.PlaceLimitOrder(100, NewOrder(true, 5))
+	assert(t, matches[0].SizeFilled, 1.234)
+	assert(t, len(ob.Asks()), 0)
+	assert(t, ob.BidTotalVolume(), 5-1.234)
+
+	// Rounding never fills more than the order has
+	assert(t, ob.Rounding.fillSize(0.126, 0.126), 0.126)
+	assert(t, (&Rounding{Places: 2}).fillSize(0.126, 5), 0.13)
+}
@@ -0,0 +1,108 @@
+package orderbook
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrInvalidActivateAt = errors.New("scheduled order needs an activation time")
+
+// Holds the order back until activateAt (unix nanos), see PlaceScheduledOrder
+func WithActivateAt(activateAt int64) OrderOption {
+	return func(o *Order) {
+		o.ActivateAt = activateAt
+	}
+}
+
+// Parks an order until its ActivateAt, e.g. to queue it for the open. Once
+// ActivateDue gets there it goes in like a stop that triggered: as a market
+// order, or as a limit order at LimitPrice when that's set.
+func (ob *Orderbook) PlaceScheduledOrder(o *Order) error {
+	if o.ActivateAt <= 0 {
+		return ErrInvalidActivateAt
+	}
+	if err := ob.checkPlacement(o, o.LimitPrice, false); err != nil {
+		return err
+	}
+	if err := ob.validateSize(o.Size); err != nil {
+		return err
+	}
+	if o.LimitPrice != 0 {
+		if err := validatePrice(o.LimitPrice); err != nil {
+			return err
+		}
+		if ob.TickSize > 0 && !isMultiple(o.LimitPrice, ob.TickSize) {
+			return ErrInvalidTick
+		}
+	}
+	if err := ob.journalOrders(opSchedule, []*Order{o}); err != nil {
+		return err
+	}
+
+	ob.scheduled = append(ob.scheduled, o)
+	return nil
+}
+
+// Scheduled orders still waiting for their activation time, in the order they were placed
+func (ob *Orderbook) PendingScheduled() []*Order {
+	scheduled := make([]*Order, len(ob.scheduled))
+	copy(scheduled, ob.scheduled)
+	return scheduled
+}
+
+// Sends in every scheduled order whose ActivateAt is at or before now (unix
+// nanos), earliest first, and returns the matches they make. A limit order the
+// book would refuse at that point (outside the band, level cap, ...) is
+// cancelled instead. While trading is halted or the trade history is full
+// everything stays parked, and during an auction the market orders do.
+func (ob *Orderbook) ActivateDue(now int64) []Match {
+	matches := []Match{}
+	if ob.halted || ob.tradeHistoryFull() {
+		return matches
+	}
+
+	var due []*Order
+	for _, o := range ob.scheduled {
+		if o.ActivateAt <= now && !(ob.auction && o.LimitPrice == 0) {
+			due = append(due, o)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].ActivateAt < due[j].ActivateAt
+	})
+
+	for _, o := range due {
+		matches = append(matches, ob.activate(o)...)
+	}
+	return matches
+}
+
+func (ob *Orderbook) activate(o *Order) []Match {
+	ob.journal(journalRecord{Op: opActivate, ID: o.ID}) // a failed write is reported by the next placement
+	ob.removeScheduled(o)
+
+	if err := ob.admitTriggered(o); err != nil {
+		ob.debug("scheduled order rejected", "id", o.ID, "err", err)
+		o.Status = StatusCancelled
+		return nil
+	}
+
+	ob.metrics().IncOrdersPlaced(o.Bid)
+	var matches []Match
+	if o.LimitPrice == 0 {
+		matches = ob.placeMarketOrder(o)
+	} else {
+		matches = ob.placeLimitOrder(o.LimitPrice, o)
+	}
+	return append(matches, ob.settle()...)
+}
+
+func (ob *Orderbook) removeScheduled(o *Order) bool {
+	for i, scheduled := range ob.scheduled {
+		if scheduled == o {
+			ob.scheduled = append(ob.scheduled[:i], ob.scheduled[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package orderbook
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func scheduledLimit(bid bool, size float64, activateAt int64, price float64) *Order {
+	o := NewOrder(bid, size, WithActivateAt(activateAt))
+	o.LimitPrice = price
+	return o
+}
+
+func TestScheduledOrderActivatesAfterItsTime(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+
+	buy := NewOrder(true, 1, WithActivateAt(1_000))
+	assert(t, ob.PlaceScheduledOrder(buy), nil)
+	assert(t, ob.PendingScheduled(), []*Order{buy})
+	assert(t, ob.BidTotalVolume(), 0.0)
+
+	assert(t, len(ob.ActivateDue(999)), 0)
+	assert(t, ob.PendingScheduled(), []*Order{buy})
+
+	// A market order once it's due
+	matches := ob.ActivateDue(1_000)
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Bid, buy)
+	assert(t, matches[0].Price, 100.0)
+	assert(t, len(ob.PendingScheduled()), 0)
+	assert(t, ob.AskTotalVolume(), 1.0)
+}
+
+func TestScheduledLimitOrderRests(t *testing.T) {
+	ob := NewOrderBook()
+	late := scheduledLimit(true, 2, 2_000, 99)
+	early := scheduledLimit(true, 1, 1_000, 99)
+	ob.PlaceScheduledOrder(late)
+	ob.PlaceScheduledOrder(early)
+
+	assert(t, len(ob.ActivateDue(5_000)), 0)
+	assert(t, len(ob.PendingScheduled()), 0)
+
+	// Earliest activation goes in first, so it's ahead in the queue
+	assert(t, ob.Bids()[0].Orders, Orders{early, late})
+}
+
+func TestScheduledOrderRejected(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, ob.PlaceScheduledOrder(NewOrder(true, 1)), ErrInvalidActivateAt)
+	assert(t, ob.PlaceScheduledOrder(NewOrder(true, 0, WithActivateAt(1))), ErrInvalidSize)
+
+	// Outside the band by the time it's due, so it's cancelled instead
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	ob.SetPriceBand(100, 10)
+	far := scheduledLimit(true, 1, 1, 50)
+	ob.PlaceScheduledOrder(far)
+	ob.ActivateDue(1)
+	assert(t, far.Status, StatusCancelled)
+	assert(t, ob.BidTotalVolume(), 1.0)
+}
+
+func TestCancelScheduledOrder(t *testing.T) {
+	ob := NewOrderBook()
+	o := scheduledLimit(true, 1, 1_000, 99)
+	ob.PlaceScheduledOrder(o)
+
+	ob.CancelOrder(o)
+	assert(t, len(ob.PendingScheduled()), 0)
+	assert(t, o.Status, StatusCancelled)
+	assert(t, len(ob.ActivateDue(1_000)), 0)
+	assert(t, len(ob.Bids()), 0)
+}
+
+func TestScheduledOrderHalted(t *testing.T) {
+	ob := NewOrderBook()
+	o := scheduledLimit(true, 1, 1_000, 99)
+	ob.PlaceScheduledOrder(o)
+
+	ob.halted = true
+	ob.ActivateDue(1_000)
+	assert(t, ob.PendingScheduled(), []*Order{o})
+
+	ob.Resume()
+	ob.ActivateDue(1_000)
+	assert(t, ob.BidTotalVolume(), 1.0)
+}
+
+func TestScheduledOrderJournalAndSnapshot(t *testing.T) {
+	ob := NewOrderBook()
+	var journal bytes.Buffer
+	ob.SetJournal(&journal)
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	ob.PlaceScheduledOrder(NewOrder(true, 1, WithActivateAt(1_000)))
+	pending := scheduledLimit(true, 1, 2_000, 99)
+	ob.PlaceScheduledOrder(pending)
+	ob.ActivateDue(1_500)
+
+	replayed, err := Replay(&journal)
+	assert(t, err, nil)
+	assertSameBook(t, ob, replayed)
+	assert(t, len(replayed.PendingScheduled()), 1)
+
+	restored := Restore(ob.Snapshot())
+	assert(t, restored.PendingScheduled()[0].ID, pending.ID)
+	assert(t, restored.PendingScheduled()[0].ActivateAt, int64(2_000))
+}
+
+func TestExpiryLoopActivatesScheduledOrders(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetClock(func() time.Time { return time.Unix(0, 500) })
+	ob.PlaceScheduledOrder(scheduledLimit(true, 1, 400, 99))
+	ob.PlaceScheduledOrder(scheduledLimit(true, 1, 600, 98))
+
+	ob.StartExpiryLoop(time.Millisecond)
+	defer ob.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ob.Lock()
+		n := ob.NumOrders()
+		ob.Unlock()
+
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expiry loop never activated the order")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ob.Lock()
+	defer ob.Unlock()
+	assert(t, len(ob.PendingScheduled()), 1)
+	assert(t, ob.BestBid().Price, 99.0)
+}
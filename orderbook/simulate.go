@@ -0,0 +1,38 @@
+package orderbook
+
+// The matches PlaceLimitOrder would return for o at price, without changing
+// the book or o. It runs the order against a throwaway Clone, so every
+// matching rule (STP, pro-rata, icebergs, stops it would trigger) applies
+// exactly as it would for real, at the cost of copying the book. The clone
+// logs, journals and reports to nothing, a dry run leaves no trace, so it
+// doesn't count against the trader's rate limit either. The matches point at
+// the real orders. Nil if the order would be rejected.
+func (ob *Orderbook) Simulate(price float64, o *Order) []Match {
+	clone := ob.Clone()
+	clone.logger = nil
+	clone.journalWriter = nil
+	clone.HistorySize = 0
+	clone.Metrics = nil
+	clone.onTopChange = nil
+	clone.candleStreams = nil
+
+	sim := *o
+	sim.Limit = nil
+	matches, err := clone.PlaceLimitOrder(price, &sim)
+	if err != nil {
+		return nil
+	}
+
+	original := func(order *Order) *Order {
+		if order == &sim {
+			return o
+		}
+		return ob.findOrder(order.ID)
+	}
+	for i := range matches {
+		matches[i].Bid = original(matches[i].Bid)
+		matches[i].Ask = original(matches[i].Ask)
+	}
+
+	return matches
+}
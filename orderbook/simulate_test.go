@@ -0,0 +1,77 @@
+package orderbook
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSimulate(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.PlaceLimitOrder(101, NewOrder(false, 2))
+	ob.PlaceLimitOrder(102, NewOrder(false, 4))
+	ob.PlaceLimitOrder(99, NewOrder(true, 2))
+
+	before := ob.Snapshot()
+	o := NewOrder(true, 5)
+
+	simulated := ob.Simulate(102, o)
+	assert(t, ob.Snapshot(), before)
+	assert(t, o.Size, 5.0)
+	assert(t, o.Limit == nil, true)
+	assert(t, len(ob.Trades()), 0)
+
+	assert(t, len(simulated), 3)
+	assert(t, simulated[0].Ask, ob.Asks()[0].Orders[0])
+	assert(t, simulated[0].Bid, o)
+	assert(t, simulated[2].Ask, ob.Asks()[1].Orders[0])
+
+	// Same as the real thing
+	matches, _ := ob.PlaceLimitOrder(102, o)
+	assert(t, len(matches), len(simulated))
+	for i := range matches {
+		assert(t, matches[i].Ask.ID, simulated[i].Ask.ID)
+		assert(t, matches[i].Bid, simulated[i].Bid)
+		assert(t, matches[i].SizeFilled, simulated[i].SizeFilled)
+		assert(t, matches[i].Price, simulated[i].Price)
+	}
+
+	// Nothing crosses, or the order is invalid
+	assert(t, len(ob.Simulate(100, NewOrder(false, 1))), 0)
+	assert(t, ob.Simulate(-1, NewOrder(false, 1)), []Match(nil))
+}
+
+func TestSimulateLeavesNoTrace(t *testing.T) {
+	var msgs []string
+	var journal bytes.Buffer
+	tops := 0
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+	ob.SetLogger(slog.New(captureHandler{&msgs}))
+	ob.SetJournal(&journal)
+	ob.OnTopOfBookChange(func(bid, ask *Limit) { tops++ })
+	cs := ob.StreamCandles(time.Minute)
+	written := journal.Len()
+
+	assert(t, len(ob.Simulate(101, NewOrder(true, 1))), 1)
+	assert(t, len(msgs), 0)
+	assert(t, journal.Len(), written)
+	assert(t, tops, 0)
+	_, open := cs.Current()
+	assert(t, open, false)
+}
+
+func TestSimulateChecksFunds(t *testing.T) {
+	ob := NewOrderBook()
+	balances := NewBalances()
+	balances.Quote["alice"] = 100
+	ob.Accounts = balances
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+	o := NewOrder(true, 5, WithTraderID("alice"))
+	assert(t, ob.Simulate(100, o), []Match(nil))
+	_, err := ob.PlaceLimitOrder(100, o)
+	assert(t, err, ErrInsufficientFunds)
+}
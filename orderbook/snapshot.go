@@ -0,0 +1,345 @@
+package orderbook
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Serializable copy of an order. It leaves out the Limit back-pointer, the
+// price lives on the LimitSnapshot that holds the order.
+type OrderSnapshot struct {
+	ID            int64
+	TraderID      string
+	Size          float64
+	Bid           bool
+	Timestamp     int64
+	TimeInForce   TimeInForce
+	ExpireAt      int64
+	MaxPrice      float64
+	MinPrice      float64
+	RestRemainder bool
+	ReduceOnly    bool
+	AON           bool
+	Stop          bool
+	StopPrice     float64
+	LimitPrice    float64
+	DisplaySize   float64
+	Hidden        float64
+	OCOID         int64
+	Peg           Peg
+	PegOffset     float64
+	Status        OrderStatus
+	Tiebreak      int64
+	ActivateAt    int64
+}
+
+type LimitSnapshot struct {
+	Price  float64
+	Orders []OrderSnapshot // in time priority
+}
+
+// A fill counted towards a trader's volume, see UserVolume
+type FillSnapshot struct {
+	Timestamp int64
+	Size      float64
+}
+
+type FeeAccrualSnapshot struct {
+	Fees    float64
+	Rebates float64
+}
+
+// Everything needed to rebuild a book: its configuration, the price levels
+// best first with their orders, and the stop orders that haven't triggered.
+// The trade tape isn't part of it, but what trading left behind is: the last
+// price (stops trigger off it), each trader's fills within the fee window and
+// their accrued fees.
+type BookSnapshot struct {
+	STP                STPPolicy
+	Matching           MatchingMode
+	PriceImprovement   PriceImprovementMode
+	TopOrderAllocation float64
+	Fees               *FeeSchedule
+	Rounding           *Rounding
+	TickSize           float64
+	LotSize            float64
+	MinSize            float64
+	RestAtEqualPrice   bool
+	MaxLevelsPerSide   int
+	EvictWorstLevel    bool
+	PriceBandRef       float64
+	PriceBandPct       float64
+	CircuitBreakerPct  float64
+	Halted             bool
+	Auction            bool
+	NextOCOID          int64
+
+	Asks      []LimitSnapshot
+	Bids      []LimitSnapshot
+	Stops     []OrderSnapshot
+	Scheduled []OrderSnapshot
+
+	LastPrice   float64 // 0 when nothing has traded yet
+	LastPriceAt int64
+	TraderFills map[string][]FillSnapshot
+	AccruedFees map[string]FeeAccrualSnapshot
+}
+
+func (ob *Orderbook) Snapshot() BookSnapshot {
+	snap := BookSnapshot{
+		STP:                ob.STP,
+		Matching:           ob.Matching,
+		PriceImprovement:   ob.PriceImprovement,
+		TopOrderAllocation: ob.TopOrderAllocation,
+		Fees:               ob.Fees,
+		Rounding:           ob.Rounding,
+		TickSize:           ob.TickSize,
+		LotSize:            ob.LotSize,
+		MinSize:            ob.MinSize,
+		RestAtEqualPrice:   ob.RestAtEqualPrice,
+		MaxLevelsPerSide:   ob.MaxLevelsPerSide,
+		EvictWorstLevel:    ob.EvictWorstLevel,
+		PriceBandRef:       ob.bandRef,
+		PriceBandPct:       ob.bandPct,
+		CircuitBreakerPct:  ob.CircuitBreakerPct,
+		Halted:             ob.halted,
+		Auction:            ob.auction,
+		NextOCOID:          ob.nextOCOID,
+		Asks:               snapshotLimits(ob.Asks()),
+		Bids:               snapshotLimits(ob.Bids()),
+		Stops:              []OrderSnapshot{},
+	}
+
+	for _, stop := range ob.stops {
+		snap.Stops = append(snap.Stops, snapshotOrder(stop))
+	}
+	for _, o := range ob.scheduled {
+		snap.Scheduled = append(snap.Scheduled, snapshotOrder(o))
+	}
+
+	if n := len(ob.priceSamples); n > 0 {
+		snap.LastPrice = ob.priceSamples[n-1].price
+		snap.LastPriceAt = ob.priceSamples[n-1].timestamp
+	}
+	for traderID, fills := range ob.userFills {
+		if snap.TraderFills == nil {
+			snap.TraderFills = make(map[string][]FillSnapshot)
+		}
+		for _, f := range fills {
+			snap.TraderFills[traderID] = append(snap.TraderFills[traderID], FillSnapshot{Timestamp: f.timestamp, Size: f.size})
+		}
+	}
+	for traderID, a := range ob.accrued {
+		if snap.AccruedFees == nil {
+			snap.AccruedFees = make(map[string]FeeAccrualSnapshot)
+		}
+		snap.AccruedFees[traderID] = FeeAccrualSnapshot{Fees: a.fees, Rebates: a.rebates}
+	}
+
+	return snap
+}
+
+// Builds a new book from a snapshot
+func Restore(snap BookSnapshot) *Orderbook {
+	ob := NewOrderBook()
+	ob.restore(snap)
+	return ob
+}
+
+func (ob *Orderbook) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ob.Snapshot())
+}
+
+func (ob *Orderbook) UnmarshalJSON(data []byte) error {
+	var snap BookSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+
+	ob.restore(snap)
+	return nil
+}
+
+// Writes a binary snapshot of the book, read it back with DecodeGob
+func (ob *Orderbook) EncodeGob(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(ob.Snapshot())
+}
+
+func DecodeGob(r io.Reader) (*Orderbook, error) {
+	var snap BookSnapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, err
+	}
+	return Restore(snap), nil
+}
+
+// Replaces the contents of the book with the snapshot, rebuilding the limits,
+// the Order.Limit back-pointers and every lookup from scratch
+func (ob *Orderbook) restore(snap BookSnapshot) {
+	if ob.now == nil {
+		ob.now = time.Now // zero value book from json.Unmarshal
+	}
+	ob.reset()
+
+	ob.STP = snap.STP
+	ob.Matching = snap.Matching
+	ob.PriceImprovement = snap.PriceImprovement
+	ob.TopOrderAllocation = snap.TopOrderAllocation
+	ob.Fees = snap.Fees
+	ob.Rounding = snap.Rounding
+	ob.TickSize = snap.TickSize
+	ob.LotSize = snap.LotSize
+	ob.MinSize = snap.MinSize
+	ob.RestAtEqualPrice = snap.RestAtEqualPrice
+	ob.MaxLevelsPerSide = snap.MaxLevelsPerSide
+	ob.EvictWorstLevel = snap.EvictWorstLevel
+	ob.bandRef = snap.PriceBandRef
+	ob.bandPct = snap.PriceBandPct
+	ob.CircuitBreakerPct = snap.CircuitBreakerPct
+	ob.halted = snap.Halted
+	ob.auction = snap.Auction
+	ob.nextOCOID = snap.NextOCOID
+
+	legs := make(map[int64][]*Order)
+
+	for _, ls := range snap.Asks {
+		for _, o := range ob.restoreLimit(false, ls) {
+			if o.OCOID != 0 {
+				legs[o.OCOID] = append(legs[o.OCOID], o)
+			}
+		}
+	}
+	for _, ls := range snap.Bids {
+		for _, o := range ob.restoreLimit(true, ls) {
+			if o.OCOID != 0 {
+				legs[o.OCOID] = append(legs[o.OCOID], o)
+			}
+		}
+	}
+	for _, s := range snap.Stops {
+		o := s.order()
+		ob.stops = append(ob.stops, o)
+		if o.OCOID != 0 {
+			legs[o.OCOID] = append(legs[o.OCOID], o)
+		}
+	}
+
+	for _, s := range snap.Scheduled {
+		ob.scheduled = append(ob.scheduled, s.order())
+	}
+
+	for _, pair := range legs {
+		if len(pair) == 2 {
+			ob.ocoSiblings[pair[0].ID] = pair[1]
+			ob.ocoSiblings[pair[1].ID] = pair[0]
+		}
+	}
+
+	if snap.LastPrice > 0 {
+		ob.priceSamples = []priceSample{{timestamp: snap.LastPriceAt, price: snap.LastPrice}}
+	}
+	for traderID, fills := range snap.TraderFills {
+		if ob.userFills == nil {
+			ob.userFills = make(map[string][]traderFill)
+		}
+		total := 0.0
+		for _, f := range fills {
+			total += f.Size
+			ob.userFills[traderID] = append(ob.userFills[traderID], traderFill{timestamp: f.Timestamp, size: f.Size, total: total})
+		}
+	}
+	for traderID, a := range snap.AccruedFees {
+		ob.accrue(traderID, a.Fees)
+		ob.accrue(traderID, -a.Rebates)
+	}
+
+	ob.flushDepth()
+}
+
+func (ob *Orderbook) restoreLimit(bid bool, ls LimitSnapshot) []*Order {
+	limit := NewLimit(ls.Price)
+	limit.book = ob
+	limit.createdAt = ob.now().UnixNano()
+	ob.addLimit(bid, limit)
+	ob.touch(bid, limit)
+
+	orders := make([]*Order, 0, len(ls.Orders))
+	for _, s := range ls.Orders {
+		o := s.order()
+		limit.AddOrder(o)
+		ob.trackOrder(o)
+		orders = append(orders, o)
+	}
+
+	return orders
+}
+
+func snapshotLimits(limits []*Limit) []LimitSnapshot {
+	snaps := make([]LimitSnapshot, 0, len(limits))
+	for _, l := range limits {
+		ls := LimitSnapshot{Price: l.Price, Orders: make([]OrderSnapshot, 0, len(l.Orders))}
+		for _, o := range l.Orders {
+			ls.Orders = append(ls.Orders, snapshotOrder(o))
+		}
+		snaps = append(snaps, ls)
+	}
+	return snaps
+}
+
+func snapshotOrder(o *Order) OrderSnapshot {
+	return OrderSnapshot{
+		ID:            o.ID,
+		TraderID:      o.TraderID,
+		Size:          o.Size,
+		Bid:           o.Bid,
+		Timestamp:     o.Timestamp,
+		TimeInForce:   o.TimeInForce,
+		ExpireAt:      o.ExpireAt,
+		MaxPrice:      o.MaxPrice,
+		MinPrice:      o.MinPrice,
+		RestRemainder: o.RestRemainder,
+		ReduceOnly:    o.ReduceOnly,
+		AON:           o.AON,
+		Stop:          o.Stop,
+		StopPrice:     o.StopPrice,
+		LimitPrice:    o.LimitPrice,
+		DisplaySize:   o.DisplaySize,
+		Hidden:        o.Hidden,
+		OCOID:         o.OCOID,
+		Peg:           o.Peg,
+		PegOffset:     o.PegOffset,
+		Status:        o.Status,
+		Tiebreak:      o.tiebreak,
+		ActivateAt:    o.ActivateAt,
+	}
+}
+
+func (s OrderSnapshot) order() *Order {
+	return &Order{
+		ID:            s.ID,
+		TraderID:      s.TraderID,
+		Size:          s.Size,
+		Bid:           s.Bid,
+		Timestamp:     s.Timestamp,
+		TimeInForce:   s.TimeInForce,
+		ExpireAt:      s.ExpireAt,
+		MaxPrice:      s.MaxPrice,
+		MinPrice:      s.MinPrice,
+		RestRemainder: s.RestRemainder,
+		ReduceOnly:    s.ReduceOnly,
+		AON:           s.AON,
+		Stop:          s.Stop,
+		StopPrice:     s.StopPrice,
+		LimitPrice:    s.LimitPrice,
+		DisplaySize:   s.DisplaySize,
+		Hidden:        s.Hidden,
+		OCOID:         s.OCOID,
+		Peg:           s.Peg,
+		PegOffset:     s.PegOffset,
+		Status:        s.Status,
+		tiebreak:      s.Tiebreak,
+		ActivateAt:    s.ActivateAt,
+	}
+}
@@ -0,0 +1,124 @@
+package orderbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func populatedBook() *Orderbook {
+	ob := NewOrderBook()
+	ob.TickSize = 0.5
+	ob.STP = STPCancelResting
+
+	ob.PlaceLimitOrder(101, NewOrder(false, 5, WithTraderID("alice")))
+	ob.PlaceLimitOrder(101, NewOrder(false, 2))
+	ob.PlaceLimitOrder(102.5, NewOrder(false, 10, WithDisplaySize(3)))
+	ob.PlaceLimitOrder(100, NewOrder(true, 4, WithTraderID("bob")))
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	ob.PlaceStopOrder(NewOrder(true, 1, WithStopPrice(105)))
+
+	return ob
+}
+
+func assertSameBook(t *testing.T, a, b *Orderbook) {
+	assert(t, b.ToSpec(), a.ToSpec())
+	assert(t, b.AskTotalVolume(), a.AskTotalVolume())
+	assert(t, b.BidTotalVolume(), a.BidTotalVolume())
+	assert(t, len(b.Orders), len(a.Orders))
+	assert(t, len(b.PendingStops()), len(a.PendingStops()))
+
+	for id, order := range a.Orders {
+		restored, ok := b.Orders[id]
+		assert(t, ok, true)
+		assert(t, restored.Size, order.Size)
+		assert(t, restored.Hidden, order.Hidden)
+		assert(t, restored.Timestamp, order.Timestamp)
+		assert(t, restored.TraderID, order.TraderID)
+		assert(t, restored.Limit.Price, order.Limit.Price)
+		assert(t, restored.Limit.book == b, true)
+	}
+}
+
+func TestSnapshotJSONRoundTrip(t *testing.T) {
+	ob := populatedBook()
+
+	data, err := json.Marshal(ob)
+	assert(t, err, nil)
+
+	restored := NewOrderBook()
+	assert(t, json.Unmarshal(data, restored), nil)
+
+	assertSameBook(t, ob, restored)
+	assert(t, restored.TickSize, 0.5)
+	assert(t, restored.STP, STPCancelResting)
+	assert(t, restored.OpenOrders("bob")[0].ID, ob.OpenOrders("bob")[0].ID)
+
+	// The restored book matches just like the original
+	matches, _ := restored.PlaceMarketOrder(NewOrder(true, 7))
+	assert(t, len(matches), 2)
+	assert(t, restored.AskTotalVolume(), 3.0)
+	assert(t, ob.AskTotalVolume(), 10.0)
+}
+
+func TestSnapshotKeepsTradingState(t *testing.T) {
+	ob := NewOrderBook()
+	ob.Fees = &FeeSchedule{Tiers: []FeeTier{{MakerRate: -0.001, TakerRate: 0.002}}}
+	ob.PlaceLimitOrder(100, NewOrder(false, 5, WithTraderID("maker")))
+	ob.PlaceMarketOrder(NewOrder(true, 1, WithTraderID("alice"))) // last price 100
+
+	// Triggered, but parked until the auction is over
+	ob.StartAuction()
+	stop := NewOrder(true, 2, WithStopPrice(99))
+	ob.PlaceStopOrder(stop)
+
+	data, err := json.Marshal(ob)
+	assert(t, err, nil)
+	restored := NewOrderBook()
+	assert(t, json.Unmarshal(data, restored), nil)
+
+	assertSameBook(t, ob, restored)
+	last, ok := restored.LastPrice()
+	assert(t, ok, true)
+	assert(t, last, 100.0)
+	assert(t, restored.UserVolume("alice", time.Hour), 1.0)
+	fees, rebates := restored.AccruedFees("maker")
+	assert(t, fees, 0.0)
+	assert(t, rebates, 0.1)
+
+	// Nothing crosses, the next placement fires the stop off the old last price
+	restored.Uncross()
+	restored.PlaceLimitOrder(90, NewOrder(true, 1))
+	assert(t, len(restored.PendingStops()), 0)
+	assert(t, restored.AskTotalVolume(), 2.0)
+}
+
+func TestSnapshotIntoZeroValueBook(t *testing.T) {
+	ob := populatedBook()
+	data, _ := json.Marshal(ob)
+
+	var restored Orderbook
+	assert(t, json.Unmarshal(data, &restored), nil)
+	assertSameBook(t, ob, &restored)
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	ob := populatedBook()
+	ob.Fees = &FeeSchedule{Tiers: []FeeTier{{MinVolume: 0, TakerRate: 0.001}}}
+
+	var buf bytes.Buffer
+	assert(t, ob.EncodeGob(&buf), nil)
+
+	restored, err := DecodeGob(&buf)
+	assert(t, err, nil)
+
+	assertSameBook(t, ob, restored)
+	assert(t, restored.Fees, ob.Fees)
+	assert(t, restored.PendingStops()[0].ID, ob.PendingStops()[0].ID)
+}
+
+func TestGobDecodeGarbage(t *testing.T) {
+	_, err := DecodeGob(bytes.NewBufferString("not a snapshot"))
+	assert(t, err != nil, true)
+}
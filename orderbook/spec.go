@@ -0,0 +1,92 @@
+package orderbook
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Builds a book from a compact text spec, one limit order per line:
+//
+//	S 5 @ 101
+//	B 10 @ 100
+//
+// Orders are placed in the order they appear, so crossing lines will match.
+// Blank lines and lines starting with # are skipped.
+func BuildFromSpec(spec string) (*Orderbook, error) {
+	ob := NewOrderBook()
+
+	scanner := bufio.NewScanner(strings.NewReader(spec))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		bid, size, price, err := parseSpecLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if _, err := ob.PlaceLimitOrder(price, NewOrder(bid, size)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+
+	return ob, scanner.Err()
+}
+
+func parseSpecLine(line string) (bid bool, size, price float64, err error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[2] != "@" {
+		return false, 0, 0, fmt.Errorf("expected \"B|S <size> @ <price>\", got %q", line)
+	}
+
+	switch fields[0] {
+	case "B":
+		bid = true
+	case "S":
+		bid = false
+	default:
+		return false, 0, 0, fmt.Errorf("unknown side %q", fields[0])
+	}
+
+	if size, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return false, 0, 0, fmt.Errorf("bad size: %w", err)
+	}
+	if price, err = strconv.ParseFloat(fields[3], 64); err != nil {
+		return false, 0, 0, fmt.Errorf("bad price: %w", err)
+	}
+
+	return bid, size, price, nil
+}
+
+// Renders the resting orders back into the spec format like a price ladder:
+// asks from the highest price down, then bids from the highest price down.
+// Orders at the same price are listed in time priority.
+func (ob *Orderbook) ToSpec() string {
+	var sb strings.Builder
+
+	asks := ob.Asks()
+	for i := len(asks) - 1; i >= 0; i-- {
+		writeSpecLimit(&sb, "S", asks[i])
+	}
+	for _, limit := range ob.Bids() {
+		writeSpecLimit(&sb, "B", limit)
+	}
+
+	return sb.String()
+}
+
+func writeSpecLimit(sb *strings.Builder, side string, l *Limit) {
+	for _, order := range l.Orders {
+		fmt.Fprintf(sb, "%s %s @ %s\n", side, formatSpecNumber(order.Size), formatSpecNumber(l.Price))
+	}
+}
+
+func formatSpecNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,45 @@
+package orderbook
+
+import "testing"
+
+func TestSpecRoundTrip(t *testing.T) {
+	spec := `S 2 @ 102
+S 5 @ 101
+S 1.5 @ 101
+B 10 @ 100
+B 3 @ 99.5
+`
+	ob, err := BuildFromSpec(spec)
+
+	assert(t, err, nil)
+	assert(t, ob.ToSpec(), spec)
+	assert(t, ob.AskTotalVolume(), 8.5)
+	assert(t, ob.BidTotalVolume(), 13.0)
+}
+
+func TestSpecMatching(t *testing.T) {
+	ob, err := BuildFromSpec(`
+# resting book
+S 5 @ 101
+S 3 @ 102
+B 4 @ 100
+
+# crosses the whole 101 level and part of 102
+B 6 @ 102
+`)
+
+	assert(t, err, nil)
+	assert(t, len(ob.Trades()), 2)
+	assert(t, ob.ToSpec(), "S 2 @ 102\nB 4 @ 100\n")
+}
+
+func TestSpecErrors(t *testing.T) {
+	_, err := BuildFromSpec("B 10 100")
+	assert(t, err != nil, true)
+
+	_, err = BuildFromSpec("X 10 @ 100")
+	assert(t, err != nil, true)
+
+	_, err = BuildFromSpec("B -1 @ 100")
+	assert(t, err != nil, true)
+}
@@ -0,0 +1,36 @@
+package orderbook
+
+// Everything a monitor usually wants to know about the book in one go
+type BookStats struct {
+	TradeCount     int
+	TradedVolume   float64 // base size traded
+	TradedNotional float64 // quote value traded, size * price summed over trades
+
+	AskLevels int
+	BidLevels int
+	Orders    int // resting orders, pending stops not included
+	AskVolume float64
+	BidVolume float64
+}
+
+func (ob *Orderbook) countTrade(m Match) {
+	ob.tradeCount++
+	ob.tradedVolume += m.SizeFilled
+	ob.tradedNotional += m.SizeFilled * m.Price
+}
+
+// Traded totals since the book was made (or last reset) and its current depth
+func (ob *Orderbook) Stats() BookStats {
+	askLevels, bidLevels := ob.NumLevels()
+
+	return BookStats{
+		TradeCount:     ob.tradeCount,
+		TradedVolume:   ob.tradedVolume,
+		TradedNotional: ob.tradedNotional,
+		AskLevels:      askLevels,
+		BidLevels:      bidLevels,
+		Orders:         ob.NumOrders(),
+		AskVolume:      ob.AskTotalVolume(),
+		BidVolume:      ob.BidTotalVolume(),
+	}
+}
@@ -0,0 +1,30 @@
+package orderbook
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, ob.Stats(), BookStats{})
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+	ob.PlaceLimitOrder(101, NewOrder(false, 3))
+	ob.PlaceLimitOrder(99, NewOrder(true, 4))
+	ob.PlaceLimitOrder(98, NewOrder(true, 1))
+
+	ob.PlaceMarketOrder(NewOrder(true, 3))     // 2 @ 100, 1 @ 101
+	ob.PlaceLimitOrder(99, NewOrder(false, 1)) // 1 @ 99
+
+	assert(t, ob.Stats(), BookStats{
+		TradeCount:     3,
+		TradedVolume:   4,
+		TradedNotional: 2*100 + 1*101 + 1*99,
+		AskLevels:      1,
+		BidLevels:      2,
+		Orders:         3,
+		AskVolume:      2,
+		BidVolume:      4,
+	})
+
+	ob.Reset()
+	assert(t, ob.Stats(), BookStats{})
+}
@@ -0,0 +1,45 @@
+package orderbook
+
+// Where an order is in its lifecycle
+type OrderStatus int
+
+const (
+	StatusNew             OrderStatus = iota // nothing has traded yet
+	StatusPartiallyFilled                    // some of the size traded, the rest is still live
+	StatusFilled                             // all of the size traded
+	StatusCancelled                          // taken off the book (or out of the stops) before it filled
+)
+
+func (s OrderStatus) String() string {
+	switch s {
+	case StatusNew:
+		return "new"
+	case StatusPartiallyFilled:
+		return "partially filled"
+	case StatusFilled:
+		return "filled"
+	case StatusCancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// Called after a fill. An iceberg whose peak traded but still has reserve is
+// only partially filled.
+func (o *Order) updateFillStatus() {
+	if o.IsFilled() && o.Hidden == 0 {
+		o.Status = StatusFilled
+	} else {
+		o.Status = StatusPartiallyFilled
+	}
+}
+
+// Looks up a live order, resting on the book or waiting as a stop. Filled and
+// cancelled orders leave the book, the *Order you placed keeps their final Status.
+func (ob *Orderbook) GetOrder(id int64) (*Order, error) {
+	o := ob.findOrder(id)
+	if o == nil {
+		return nil, ErrOrderNotFound
+	}
+	return o, nil
+}
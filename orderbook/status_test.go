@@ -0,0 +1,68 @@
+package orderbook
+
+import "testing"
+
+func TestOrderStatusLifecycle(t *testing.T) {
+	ob := NewOrderBook()
+	o := NewOrder(false, 10)
+	assert(t, o.Status, StatusNew)
+
+	ob.PlaceLimitOrder(100, o)
+	got, err := ob.GetOrder(o.ID)
+	assert(t, err, nil)
+	assert(t, got.Status, StatusNew)
+
+	taker := NewOrder(true, 4)
+	ob.PlaceMarketOrder(taker)
+	assert(t, o.Status, StatusPartiallyFilled)
+	assert(t, taker.Status, StatusFilled)
+
+	ob.PlaceMarketOrder(NewOrder(true, 6))
+	assert(t, o.Status, StatusFilled)
+	_, err = ob.GetOrder(o.ID)
+	assert(t, err, ErrOrderNotFound)
+
+	c := NewOrder(true, 5)
+	ob.PlaceLimitOrder(99, c)
+	ob.PlaceMarketOrder(NewOrder(false, 2))
+	assert(t, c.Status, StatusPartiallyFilled)
+	ob.CancelOrder(c)
+	assert(t, c.Status, StatusCancelled)
+	assert(t, c.Status.String(), "cancelled")
+}
+
+func TestIcebergStaysPartiallyFilled(t *testing.T) {
+	ob := NewOrderBook()
+	iceberg := NewOrder(false, 6, WithDisplaySize(2))
+	ob.PlaceLimitOrder(100, iceberg)
+
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+	assert(t, iceberg.Status, StatusPartiallyFilled)
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 4)) // market orders only see the visible peak
+	assert(t, iceberg.Status, StatusFilled)
+}
+
+func TestStopOrderStatus(t *testing.T) {
+	ob := NewOrderBook()
+	stop := NewOrder(true, 1, WithStopPrice(105))
+	ob.PlaceStopOrder(stop)
+
+	got, err := ob.GetOrder(stop.ID)
+	assert(t, err, nil)
+	assert(t, got, stop)
+
+	ob.CancelOrder(stop)
+	assert(t, stop.Status, StatusCancelled)
+}
+
+func TestSelfTradeCancelMarksCancelled(t *testing.T) {
+	ob := NewOrderBook()
+	ob.STP = STPCancelResting
+	resting := NewOrder(false, 2, WithTraderID("alice"))
+	ob.PlaceLimitOrder(100, resting)
+	ob.PlaceLimitOrder(100, NewOrder(false, 2, WithTraderID("bob")))
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 2, WithTraderID("alice")))
+	assert(t, resting.Status, StatusCancelled)
+}
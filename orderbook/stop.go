@@ -0,0 +1,139 @@
+package orderbook
+
+import "errors"
+
+var ErrInvalidStopPrice = errors.New("stop price must be a positive, finite number")
+
+// Turns the order into a stop order that triggers at stopPrice
+func WithStopPrice(stopPrice float64) OrderOption {
+	return func(o *Order) {
+		o.Stop = true
+		o.StopPrice = stopPrice
+	}
+}
+
+// Turns the order into a stop-limit order, once stopPrice triggers it is placed
+// as a limit order at limitPrice
+func WithStopLimit(stopPrice, limitPrice float64) OrderOption {
+	return func(o *Order) {
+		o.Stop = true
+		o.StopPrice = stopPrice
+		o.LimitPrice = limitPrice
+	}
+}
+
+// Parks a stop order until the last traded price reaches its StopPrice, after
+// which it is sent in as a market order, or as a limit order at LimitPrice for
+// stop-limits. A stop that is already triggered by the current last price goes
+// in right away, or for a stop-market during an auction, once it's uncrossed.
+func (ob *Orderbook) PlaceStopOrder(o *Order) ([]Match, error) {
+	if err := ob.checkPlacement(o, o.LimitPrice, false); err != nil {
+		return nil, err
+	}
+	if err := ob.validateSize(o.Size); err != nil {
+		return nil, err
+	}
+	if validatePrice(o.StopPrice) != nil {
+		return nil, ErrInvalidStopPrice
+	}
+	if o.LimitPrice != 0 {
+		if err := validatePrice(o.LimitPrice); err != nil {
+			return nil, err
+		}
+		if ob.TickSize > 0 && !isMultiple(o.LimitPrice, ob.TickSize) {
+			return nil, ErrInvalidTick
+		}
+	}
+
+	o.Stop = true
+	if err := ob.journalOrders(opStop, []*Order{o}); err != nil {
+		return nil, err
+	}
+
+	ob.stops = append(ob.stops, o)
+
+	return ob.settle(), nil
+}
+
+// Stop orders that haven't triggered yet, oldest first
+func (ob *Orderbook) PendingStops() []*Order {
+	stops := make([]*Order, len(ob.stops))
+	copy(stops, ob.stops)
+	return stops
+}
+
+func (o *Order) stopTriggered(lastPrice float64) bool {
+	if o.Bid {
+		return lastPrice >= o.StopPrice
+	}
+	return lastPrice <= o.StopPrice
+}
+
+// Sends in every stop whose trigger has been crossed by the last price. Since
+// those fills move the last price again this keeps going until no more stops
+// trigger, and returns the whole cascade of matches.
+func (ob *Orderbook) activateStops() []Match {
+	matches := []Match{}
+
+	for {
+		lastPrice, ok := ob.LastPrice()
+		if !ok || ob.halted || ob.tradeHistoryFull() {
+			return matches
+		}
+
+		// During an auction stop-markets stay parked like scheduled market
+		// orders, they fire once the uncross is done
+		var triggered *Order
+		for _, stop := range ob.stops {
+			if stop.stopTriggered(lastPrice) && !(ob.auction && stop.LimitPrice == 0) {
+				triggered = stop
+				break
+			}
+		}
+
+		if triggered == nil {
+			return matches
+		}
+
+		ob.removeStop(triggered)
+
+		// The book may have changed since the stop was parked, e.g. its limit
+		// price is outside the band now. Then it's cancelled instead.
+		if err := ob.admitTriggered(triggered); err != nil {
+			ob.debug("triggered order rejected", "id", triggered.ID, "err", err)
+			ob.unlinkOCO(triggered)
+			triggered.Status = StatusCancelled
+			continue
+		}
+
+		if triggered.LimitPrice != 0 {
+			// A stop-limit that doesn't cross just rests at its limit price
+			matches = append(matches, ob.placeLimitOrder(triggered.LimitPrice, triggered)...)
+		} else {
+			matches = append(matches, ob.placeMarketOrder(triggered)...)
+		}
+
+		ob.cancelOCOSiblings()
+	}
+}
+
+// admit for a stop or scheduled order going in: a limit order at LimitPrice,
+// or a market order when that's 0
+func (ob *Orderbook) admitTriggered(o *Order) error {
+	if o.LimitPrice == 0 {
+		return ob.admit(o, o.protectionPrice(), o.RestRemainder)
+	}
+	if err := ob.admit(o, o.LimitPrice, true); err != nil {
+		return err
+	}
+	return ob.validateLimit(o.LimitPrice, o)
+}
+
+func (ob *Orderbook) removeStop(o *Order) {
+	for i := 0; i < len(ob.stops); i++ {
+		if ob.stops[i] == o {
+			ob.stops = append(ob.stops[:i], ob.stops[i+1:]...)
+			return
+		}
+	}
+}
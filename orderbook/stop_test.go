@@ -0,0 +1,117 @@
+package orderbook
+
+import "testing"
+
+func TestStopOrderTriggers(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 1))
+	ob.PlaceLimitOrder(10_100, NewOrder(false, 5))
+
+	stop := NewOrder(true, 2, WithStopPrice(10_000))
+	matches, err := ob.PlaceStopOrder(stop)
+	assert(t, err, nil)
+	assert(t, len(matches), 0)
+	assert(t, ob.PendingStops(), []*Order{stop})
+
+	// Trading at 10,000 triggers the stop, which buys at 10,100
+	matches, _ = ob.PlaceMarketOrder(NewOrder(true, 1))
+
+	assert(t, len(matches), 2)
+	assert(t, matches[0].Price, 10_000.0)
+	assert(t, matches[1].Bid, stop)
+	assert(t, matches[1].Price, 10_100.0)
+	assert(t, matches[1].SizeFilled, 2.0)
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, ob.AskTotalVolume(), 3.0)
+}
+
+func TestSellStopCascade(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(true, 1))
+	ob.PlaceLimitOrder(9_900, NewOrder(true, 1))
+	ob.PlaceLimitOrder(9_800, NewOrder(true, 1))
+
+	first := NewOrder(false, 1, WithStopPrice(10_000))
+	second := NewOrder(false, 1, WithStopPrice(9_900))
+	ob.PlaceStopOrder(second)
+	ob.PlaceStopOrder(first)
+
+	// The first stop's fill at 9,900 is what triggers the second
+	matches, _ := ob.PlaceLimitOrder(10_000, NewOrder(false, 1))
+
+	assert(t, len(matches), 3)
+	assert(t, matches[1].Ask, first)
+	assert(t, matches[1].Price, 9_900.0)
+	assert(t, matches[2].Ask, second)
+	assert(t, matches[2].Price, 9_800.0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+}
+
+func TestStopOrderNotTriggered(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 5))
+
+	stop := NewOrder(true, 1, WithStopPrice(10_500))
+	ob.PlaceStopOrder(stop)
+	ob.PlaceMarketOrder(NewOrder(true, 1))
+
+	assert(t, ob.PendingStops(), []*Order{stop})
+
+	ob.CancelOrder(stop)
+	assert(t, len(ob.PendingStops()), 0)
+
+	_, err := ob.PlaceStopOrder(NewOrder(true, 1))
+	assert(t, err, ErrInvalidStopPrice)
+}
+
+func TestStopLimitTriggersAndFills(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 1))
+	ob.PlaceLimitOrder(10_050, NewOrder(false, 2))
+	ob.PlaceLimitOrder(10_200, NewOrder(false, 2))
+
+	stop := NewOrder(true, 3, WithStopLimit(10_000, 10_100))
+	ob.PlaceStopOrder(stop)
+
+	matches, _ := ob.PlaceMarketOrder(NewOrder(true, 1))
+
+	// Only the 10,050 level is inside the limit, the rest of the order rests at 10,100
+	assert(t, len(matches), 2)
+	assert(t, matches[1].Bid, stop)
+	assert(t, matches[1].Price, 10_050.0)
+	assert(t, matches[1].SizeFilled, 2.0)
+	assert(t, stop.Size, 1.0)
+	assert(t, stop.Limit, ob.BidLimits[10_100])
+	assert(t, ob.Orders[stop.ID], stop)
+	assert(t, ob.AskTotalVolume(), 2.0)
+}
+
+func TestStopLimitRestsWhenNotCrossing(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(10_000, NewOrder(true, 1))
+	ob.PlaceLimitOrder(9_000, NewOrder(true, 1))
+
+	stop := NewOrder(false, 2, WithStopLimit(10_000, 9_500))
+	ob.PlaceStopOrder(stop)
+	assert(t, stop.Limit == nil, true)
+
+	ob.PlaceMarketOrder(NewOrder(false, 1))
+
+	assert(t, len(ob.PendingStops()), 0)
+	assert(t, stop.Limit, ob.AskLimits[9_500])
+	assert(t, ob.AskTotalVolume(), 2.0)
+	assert(t, ob.BidTotalVolume(), 1.0)
+}
+
+func TestStopLimitValidation(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TickSize = 1
+
+	_, err := ob.PlaceStopOrder(NewOrder(true, 1, WithStopLimit(100, -1)))
+	assert(t, err, ErrInvalidPrice)
+
+	_, err = ob.PlaceStopOrder(NewOrder(true, 1, WithStopLimit(100, 100.5)))
+	assert(t, err, ErrInvalidTick)
+
+	assert(t, len(ob.PendingStops()), 0)
+}
@@ -0,0 +1,60 @@
+package orderbook
+
+import "math"
+
+// Applies the book's STP policy to a resting and an incoming order from the
+// same trader. Returns whether the resting order has to come off the level.
+// An incoming order the policy cancels is marked StatusCancelled, which
+// stops it matching any further (see done).
+func (l *Limit) preventSelfTrade(resting, incoming *Order) bool {
+	switch l.selfTradePolicy() {
+	case STPCancelOldest:
+		resting.Status = StatusCancelled
+		return true
+	case STPCancelNewest:
+		incoming.Status = StatusCancelled
+		return false
+	case STPCancelBoth:
+		resting.Status = StatusCancelled
+		incoming.Status = StatusCancelled
+		return true
+	case STPDecrementAndCancel:
+		return l.decrementAndCancel(resting, incoming)
+	}
+	return false
+}
+
+// Takes the smaller order's size off both orders instead of trading it. The
+// smaller one is cancelled, the larger one keeps what's left: the incoming
+// order goes on matching, the resting one keeps its place in the queue (its
+// iceberg reserve shrinks first).
+func (l *Limit) decrementAndCancel(resting, incoming *Order) bool {
+	restingSize := resting.Size + resting.Hidden
+
+	if restingSize <= incoming.Size {
+		incoming.Size -= restingSize
+		if incoming.IsFilled() {
+			incoming.Status = StatusCancelled
+		}
+		resting.Status = StatusCancelled
+		return true
+	}
+
+	fromHidden := math.Min(resting.Hidden, incoming.Size)
+	fromVisible := incoming.Size - fromHidden
+	resting.Hidden -= fromHidden
+	resting.Size -= fromVisible
+	l.addVolume(resting.Bid, -fromVisible)
+	if l.book != nil {
+		l.book.publishOrder(OrderModified, resting, l.Price)
+	}
+
+	incoming.Size = 0
+	incoming.Status = StatusCancelled
+	return false
+}
+
+// Whether an incoming order is finished matching, filled or cancelled by STP
+func (o *Order) done() bool {
+	return o.IsFilled() || o.Status == StatusCancelled
+}
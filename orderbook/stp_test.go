@@ -0,0 +1,127 @@
+package orderbook
+
+import "testing"
+
+// alice rests 5 at 100, then buys 8 into it with bob's 3 behind her
+func selfTradeBook(stp STPPolicy) (*Orderbook, *Order, *Order) {
+	ob := NewOrderBook()
+	ob.STP = stp
+
+	own := NewOrder(false, 5, WithTraderID("alice"))
+	other := NewOrder(false, 3, WithTraderID("bob"))
+	ob.PlaceLimitOrder(100, own)
+	ob.PlaceLimitOrder(100, other)
+
+	return ob, own, other
+}
+
+func TestSTPDefaultsToCancelNewest(t *testing.T) {
+	assert(t, NewOrderBook().STP, STPCancelNewest)
+}
+
+func TestSTPCancelNewest(t *testing.T) {
+	ob, own, other := selfTradeBook(STPCancelNewest)
+
+	buy := NewOrder(true, 8, WithTraderID("alice"))
+	matches, _ := ob.PlaceLimitOrder(100, buy)
+
+	assert(t, len(matches), 0)
+	assert(t, buy.Status, StatusCancelled)
+	assert(t, buy.Limit == nil, true)
+	assert(t, ob.BidTotalVolume(), 0.0)
+	assert(t, ob.AskTotalVolume(), 8.0)
+	assert(t, ob.Orders[own.ID], own)
+	assert(t, ob.Orders[other.ID], other)
+}
+
+func TestSTPCancelOldest(t *testing.T) {
+	ob, own, other := selfTradeBook(STPCancelOldest)
+
+	buy := NewOrder(true, 8, WithTraderID("alice"))
+	matches, _ := ob.PlaceLimitOrder(100, buy)
+
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Ask, other)
+	assert(t, own.Status, StatusCancelled)
+	assert(t, buy.Size, 5.0) // the rest goes on the book
+	assert(t, ob.BidTotalVolume(), 5.0)
+	assert(t, ob.AskTotalVolume(), 0.0)
+}
+
+func TestSTPCancelBoth(t *testing.T) {
+	ob, own, other := selfTradeBook(STPCancelBoth)
+
+	buy := NewOrder(true, 8, WithTraderID("alice"))
+	matches, _ := ob.PlaceLimitOrder(100, buy)
+
+	assert(t, len(matches), 0)
+	assert(t, own.Status, StatusCancelled)
+	assert(t, buy.Status, StatusCancelled)
+	assert(t, ob.BidTotalVolume(), 0.0)
+	assert(t, ob.AskTotalVolume(), 3.0)
+	assert(t, ob.Asks()[0].Orders, Orders{other})
+}
+
+func TestSTPDecrementAndCancel(t *testing.T) {
+	// the incoming order is bigger: the resting one goes, the rest keeps matching
+	ob, own, other := selfTradeBook(STPDecrementAndCancel)
+
+	buy := NewOrder(true, 6, WithTraderID("alice"))
+	matches, _ := ob.PlaceLimitOrder(100, buy)
+
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Ask, other)
+	assert(t, matches[0].SizeFilled, 1.0)
+	assert(t, own.Status, StatusCancelled)
+	assert(t, buy.IsFilled(), true)
+	assert(t, ob.AskTotalVolume(), 2.0)
+
+	// the resting order is bigger: it shrinks and keeps its place
+	ob, own, _ = selfTradeBook(STPDecrementAndCancel)
+
+	buy = NewOrder(true, 2, WithTraderID("alice"))
+	matches, _ = ob.PlaceLimitOrder(100, buy)
+
+	assert(t, len(matches), 0)
+	assert(t, buy.Status, StatusCancelled)
+	assert(t, own.Size, 3.0)
+	assert(t, ob.Asks()[0].Orders[0], own)
+	assert(t, ob.AskTotalVolume(), 6.0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+}
+
+func TestSTPDecrementIcebergReserveFirst(t *testing.T) {
+	ob := NewOrderBook()
+	ob.STP = STPDecrementAndCancel
+
+	own := NewOrder(false, 10, WithTraderID("alice"), WithDisplaySize(4))
+	ob.PlaceLimitOrder(100, own)
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 5, WithTraderID("alice")))
+
+	assert(t, own.Size, 4.0)
+	assert(t, own.Hidden, 1.0)
+	assert(t, ob.AskTotalVolume(), 4.0)
+}
+
+func TestSTPProRata(t *testing.T) {
+	ob, own, other := selfTradeBook(STPCancelNewest)
+	ob.Matching = ProRata
+
+	buy := NewOrder(true, 8, WithTraderID("alice"))
+	matches, _ := ob.PlaceLimitOrder(100, buy)
+
+	assert(t, len(matches), 0)
+	assert(t, buy.Status, StatusCancelled)
+	assert(t, ob.AskTotalVolume(), 8.0)
+
+	ob, own, other = selfTradeBook(STPCancelOldest)
+	ob.Matching = ProRata
+
+	matches, _ = ob.PlaceMarketOrder(NewOrder(true, 8, WithTraderID("alice")))
+
+	assert(t, len(matches), 1)
+	assert(t, matches[0].Ask, other)
+	assert(t, own.Status, StatusCancelled)
+	assert(t, ob.AskTotalVolume(), 0.0)
+}
@@ -0,0 +1,56 @@
+package orderbook
+
+import (
+	"sort"
+	"time"
+)
+
+// Summary of how long price levels lived before their last order left
+type SurvivalStats struct {
+	Count  int
+	Min    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+	Median time.Duration
+}
+
+func (ob *Orderbook) recordSurvival(l *Limit) {
+	if l.book != ob {
+		return // not a level this book created
+	}
+	ob.levelSurvival = append(ob.levelSurvival, time.Duration(ob.now().UnixNano()-l.createdAt))
+}
+
+// How long every cleared price level lived, in the order they were cleared
+func (ob *Orderbook) LevelSurvival() []time.Duration {
+	survival := make([]time.Duration, len(ob.levelSurvival))
+	copy(survival, ob.levelSurvival)
+	return survival
+}
+
+func (ob *Orderbook) LevelSurvivalStats() SurvivalStats {
+	survival := ob.LevelSurvival()
+	if len(survival) == 0 {
+		return SurvivalStats{}
+	}
+
+	sort.Slice(survival, func(i, j int) bool { return survival[i] < survival[j] })
+
+	var total time.Duration
+	for _, d := range survival {
+		total += d
+	}
+
+	median := survival[len(survival)/2]
+	if len(survival)%2 == 0 {
+		median = (survival[len(survival)/2-1] + median) / 2
+	}
+
+	return SurvivalStats{
+		Count:  len(survival),
+		Min:    survival[0],
+		Max:    survival[len(survival)-1],
+		Mean:   total / time.Duration(len(survival)),
+		Median: median,
+	}
+}
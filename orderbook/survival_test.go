@@ -0,0 +1,42 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLevelSurvival(t *testing.T) {
+	ob := NewOrderBook()
+	now := time.Unix(1_700_000_000, 0)
+	ob.SetClock(func() time.Time { return now })
+
+	sellA := NewOrder(false, 1)
+	sellB := NewOrder(false, 1)
+	ob.PlaceLimitOrder(10_000, sellA)
+	ob.PlaceLimitOrder(10_100, sellB)
+
+	now = now.Add(2 * time.Second)
+	ob.CancelOrder(sellA)
+
+	// A second order at the same price doesn't restart the level's clock
+	now = now.Add(time.Second)
+	ob.PlaceLimitOrder(10_100, NewOrder(false, 1))
+
+	now = now.Add(5 * time.Second)
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+
+	assert(t, ob.LevelSurvival(), []time.Duration{2 * time.Second, 8 * time.Second})
+
+	stats := ob.LevelSurvivalStats()
+	assert(t, stats.Count, 2)
+	assert(t, stats.Min, 2*time.Second)
+	assert(t, stats.Max, 8*time.Second)
+	assert(t, stats.Mean, 5*time.Second)
+	assert(t, stats.Median, 5*time.Second)
+}
+
+func TestLevelSurvivalEmpty(t *testing.T) {
+	ob := NewOrderBook()
+	assert(t, len(ob.LevelSurvival()), 0)
+	assert(t, ob.LevelSurvivalStats(), SurvivalStats{})
+}
@@ -0,0 +1,34 @@
+package orderbook
+
+// Orders that arrive in the same nanosecond would otherwise be ordered however
+// the sort happens to leave them. They're ordered by their tiebreak key and
+// then by id, so time priority is always deterministic. The key is 0 unless
+// the book has a TiebreakRand, which draws a random one per order for
+// simulations that want simultaneous orders allocated fairly.
+
+// Whether o goes ahead of other in time priority
+func (o *Order) ahead(other *Order) bool {
+	if o.Timestamp != other.Timestamp {
+		return o.Timestamp < other.Timestamp
+	}
+	if o.tiebreak != other.tiebreak {
+		return o.tiebreak < other.tiebreak
+	}
+	return o.ID < other.ID
+}
+
+// Moves the order just appended to the level ahead of any orders with the
+// same timestamp it should be in front of
+func (l *Limit) placeAmongTies(o *Order) {
+	if l.book != nil && l.book.TiebreakRand != nil && o.tiebreak == 0 {
+		o.tiebreak = l.book.TiebreakRand.Int63()
+	}
+
+	for i := len(l.Orders) - 1; i > 0; i-- {
+		prev := l.Orders[i-1]
+		if prev.Timestamp != o.Timestamp || !o.ahead(prev) {
+			return
+		}
+		l.Orders[i-1], l.Orders[i] = o, prev
+	}
+}
@@ -0,0 +1,77 @@
+package orderbook
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func sameInstant(orders ...*Order) {
+	for _, o := range orders {
+		o.Timestamp = 1_700_000_000
+	}
+}
+
+func ids(orders []*Order) []int64 {
+	ids := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+	}
+	return ids
+}
+
+func TestEqualTimestampsQueueByID(t *testing.T) {
+	ob := NewOrderBook()
+	a := NewOrderWithID(3, false, 1)
+	b := NewOrderWithID(1, false, 1)
+	c := NewOrderWithID(2, false, 1)
+	d := NewOrderWithID(4, false, 1)
+	sameInstant(a, b, c, d)
+
+	for _, o := range []*Order{a, b, c, d} {
+		ob.PlaceLimitOrder(100, o)
+	}
+	assert(t, ids(ob.BestAsk().Orders), []int64{1, 2, 3, 4})
+
+	// Stays put when something leaves the level
+	ob.CancelOrder(c)
+	assert(t, ids(ob.BestAsk().Orders), []int64{1, 3, 4})
+
+	matches, _ := ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, matches[0].Ask, b)
+}
+
+func TestRandomTiebreak(t *testing.T) {
+	place := func(seed int64) []int64 {
+		ob := NewOrderBook()
+		ob.TiebreakRand = rand.New(rand.NewSource(seed))
+
+		orders := []*Order{}
+		for id := int64(1); id <= 8; id++ {
+			orders = append(orders, NewOrderWithID(id, true, 1))
+		}
+		sameInstant(orders...)
+		for _, o := range orders {
+			ob.PlaceLimitOrder(100, o)
+		}
+
+		// Queue order is the same after a cancel reshuffles the level
+		queue := ids(ob.BestBid().Orders)
+		ob.CancelOrder(ob.Orders[queue[3]])
+		assert(t, ids(ob.BestBid().Orders), append(append([]int64{}, queue[:3]...), queue[4:]...))
+
+		return queue
+	}
+
+	first := place(42)
+	assert(t, place(42), first) // same seed, same allocation
+	assert(t, first[0] != 1 || first[1] != 2 || first[2] != 3, true)
+
+	// Orders from different instants keep plain time priority
+	ob := NewOrderBook()
+	ob.TiebreakRand = rand.New(rand.NewSource(42))
+	early, late := NewOrderWithID(2, true, 1), NewOrderWithID(1, true, 1)
+	early.Timestamp, late.Timestamp = 1, 2
+	ob.PlaceLimitOrder(100, early)
+	ob.PlaceLimitOrder(100, late)
+	assert(t, ids(ob.BestBid().Orders), []int64{2, 1})
+}
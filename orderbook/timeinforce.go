@@ -0,0 +1,73 @@
+package orderbook
+
+import "errors"
+
+var ErrFillOrKill = errors.New("fill-or-kill order could not be filled completely")
+
+// How long an order stays active on the book
+type TimeInForce int
+
+const (
+	GTC TimeInForce = iota // good till cancelled, the remainder rests on the book
+	FOK                    // fill or kill, fill the whole size right away or do nothing
+	GTD                    // good till date, rests until ExpireAt, see ExpireOrders
+	IOC                    // immediate or cancel, trade what it can right away and drop the rest
+)
+
+func WithTimeInForce(tif TimeInForce) OrderOption {
+	return func(o *Order) {
+		o.TimeInForce = tif
+	}
+}
+
+// Size the incoming order could trade right now at the given limit price,
+// counting every level that is at or better than the price, not just the
+// best one. Orders from the same trader never match: with STPSkip and
+// STPCancelOldest they're stepped over, with the other policies the incoming
+// order stops at the first one it reaches, so the counting stops there too.
+func (ob *Orderbook) fillableVolume(o *Order, price float64) float64 {
+	if ob.auction {
+		return 0 // nothing trades until the uncross
+	}
+
+	var (
+		limits []*Limit
+		volume float64
+	)
+
+	if o.Bid {
+		limits = ob.Asks()
+	} else {
+		limits = ob.Bids()
+	}
+
+	for _, limit := range limits {
+		if !ob.crosses(o.Bid, price, limit.Price) {
+			break // sorted best first, so nothing further can cross
+		}
+
+		levelVolume := 0.0
+		for _, order := range limit.Orders {
+			if isSelfTrade(order, o) {
+				if ob.STP == STPSkip || ob.STP == STPCancelOldest {
+					continue
+				}
+				if ob.Matching != FIFO {
+					return volume // pro-rata meets it before sharing the level out, nothing here trades
+				}
+				return volume + levelVolume
+			}
+			// an AON order only counts if what's still to fill takes all of it
+			if canTakeAll(o.Size-volume-levelVolume, order) {
+				levelVolume += order.Size + order.Hidden
+			}
+		}
+		volume += levelVolume
+
+		if volume >= o.Size {
+			break
+		}
+	}
+
+	return volume
+}
@@ -0,0 +1,109 @@
+package orderbook
+
+import "testing"
+
+func TestFillOrKillAcrossLevels(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(9_000, NewOrder(false, 4))
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 4))
+
+	// Neither level covers 7 on its own, together they do
+	buyOrder := NewOrder(true, 7, WithTimeInForce(FOK))
+	matches, err := ob.PlaceLimitOrder(10_000, buyOrder)
+
+	assert(t, err, nil)
+	assert(t, len(matches), 2)
+	assert(t, matches[0].Price, 9_000.0)
+	assert(t, matches[0].SizeFilled, 4.0)
+	assert(t, matches[1].Price, 10_000.0)
+	assert(t, matches[1].SizeFilled, 3.0)
+	assert(t, buyOrder.IsFilled(), true)
+	assert(t, ob.AskTotalVolume(), 1.0)
+}
+
+func TestFillOrKillRejected(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(9_000, NewOrder(false, 4))
+	ob.PlaceLimitOrder(11_000, NewOrder(false, 4))
+
+	// The 11,000 level is beyond the limit price so only 4 is fillable
+	buyOrder := NewOrder(true, 7, WithTimeInForce(FOK))
+	matches, err := ob.PlaceLimitOrder(10_000, buyOrder)
+
+	assert(t, err, ErrFillOrKill)
+	assert(t, len(matches), 0)
+	assert(t, buyOrder.Size, 7.0)
+	assert(t, ob.AskTotalVolume(), 8.0)
+	assert(t, len(ob.Bids()), 0)
+}
+
+func TestImmediateOrCancelPartialFill(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(9_000, NewOrder(false, 4))
+	ob.PlaceLimitOrder(11_000, NewOrder(false, 4))
+
+	// crosses the 9,000 level, the rest would have rested at 10,000
+	buyOrder := NewOrder(true, 7, WithTimeInForce(IOC))
+	matches, err := ob.PlaceLimitOrder(10_000, buyOrder)
+
+	assert(t, err, nil)
+	assert(t, len(matches), 1)
+	assert(t, matches[0].SizeFilled, 4.0)
+	assert(t, buyOrder.Size, 3.0) // never filled
+	assert(t, buyOrder.Status, StatusCancelled)
+	assert(t, buyOrder.Limit == nil, true)
+	assert(t, len(ob.Bids()), 0)
+	assert(t, ob.BidTotalVolume(), 0.0)
+	_, ok := ob.Orders[buyOrder.ID]
+	assert(t, ok, false)
+}
+
+func TestImmediateOrCancelNoCross(t *testing.T) {
+	ob := NewOrderBook()
+	ob.MaxLevelsPerSide = 1
+	ob.PlaceLimitOrder(9_500, NewOrder(true, 1))
+	ob.PlaceLimitOrder(10_000, NewOrder(false, 4))
+
+	// nothing to trade with and it wouldn't rest, so the level cap doesn't matter
+	buyOrder := NewOrder(true, 2, WithTimeInForce(IOC))
+	matches, err := ob.PlaceLimitOrder(9_000, buyOrder)
+
+	assert(t, err, nil)
+	assert(t, len(matches), 0)
+	assert(t, buyOrder.Status, StatusCancelled)
+	assert(t, len(ob.Bids()), 1)
+}
+
+func TestFillOrKillStopsAtOwnOrder(t *testing.T) {
+	ob := NewOrderBook() // STPCancelNewest by default
+	ob.PlaceLimitOrder(100, NewOrder(false, 1, WithTraderID("bob")))
+	ob.PlaceLimitOrder(100, NewOrder(false, 1, WithTraderID("alice")))
+	ob.PlaceLimitOrder(100, NewOrder(false, 5, WithTraderID("bob")))
+
+	// Alice's buy would be cancelled at her own ask after 1, so it's killed up front
+	buy := NewOrder(true, 2, WithTraderID("alice"), WithTimeInForce(FOK))
+	matches, err := ob.PlaceLimitOrder(100, buy)
+	assert(t, err, ErrFillOrKill)
+	assert(t, len(matches), 0)
+	assert(t, ob.AskTotalVolume(), 7.0)
+
+	// Skipping her own order, bob's 5 behind it covers the rest
+	ob.STP = STPSkip
+	matches, err = ob.PlaceLimitOrder(100, NewOrder(true, 2, WithTraderID("alice"), WithTimeInForce(FOK)))
+	assert(t, err, nil)
+	assert(t, len(matches), 2)
+}
+
+func TestFillOrKillNeverRests(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(100, NewOrder(false, 2))
+
+	// Straight past the fillable check, as if it had got the book wrong
+	fok := NewOrder(true, 5, WithTimeInForce(FOK))
+	matches := ob.placeLimitOrder(100, fok)
+	assert(t, len(matches), 1)
+	assert(t, fok.Status, StatusCancelled)
+	assert(t, fok.Limit == nil, true)
+	assert(t, ob.BidTotalVolume(), 0.0)
+	assert(t, len(ob.Orders), 0)
+}
@@ -0,0 +1,61 @@
+package orderbook
+
+// Calls fn with the best bid and best ask levels (nil for an empty side)
+// whenever either best price changes. It's checked as each operation
+// finishes, so an order that only adds size at the top, or trades without
+// clearing the level, doesn't fire it. Passing nil stops the notifications.
+func (ob *Orderbook) OnTopOfBookChange(fn func(bid, ask *Limit)) {
+	ob.onTopChange = fn
+	ob.topBid, ob.topAsk = topPrice(ob.BestBid()), topPrice(ob.BestAsk())
+}
+
+func topPrice(l *Limit) float64 {
+	if l == nil {
+		return 0 // prices are always positive, so 0 is an empty side
+	}
+	return l.Price
+}
+
+func (ob *Orderbook) notifyTopOfBook() {
+	if ob.onTopChange == nil {
+		return
+	}
+
+	bestBid, bestAsk := ob.BestBid(), ob.BestAsk()
+	bid, ask := topPrice(bestBid), topPrice(bestAsk)
+	if bid == ob.topBid && ask == ob.topAsk {
+		return
+	}
+
+	ob.topBid, ob.topAsk = bid, ask
+	ob.onTopChange(bestBid, bestAsk)
+}
+
+// The top of the book in one read. An empty side has price and size 0, and
+// LastPrice is 0 until something trades.
+type Ticker struct {
+	BidPrice  float64
+	BidSize   float64
+	AskPrice  float64
+	AskSize   float64
+	LastPrice float64
+	Timestamp int64 // book clock, unix nanos
+}
+
+// The best bid and ask with their sizes and the last trade price, all read
+// under the book's lock so they belong together even while another goroutine
+// trades. Like PlaceBatch, don't call it while holding Lock.
+func (ob *Orderbook) Ticker() Ticker {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	t := Ticker{Timestamp: ob.now().UnixNano()}
+	if bid := ob.BestBid(); bid != nil {
+		t.BidPrice, t.BidSize = bid.Price, bid.TotalVolume
+	}
+	if ask := ob.BestAsk(); ask != nil {
+		t.AskPrice, t.AskSize = ask.Price, ask.TotalVolume
+	}
+	t.LastPrice, _ = ob.LastPrice()
+	return t
+}
@@ -0,0 +1,96 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnTopOfBookChange(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PlaceLimitOrder(101, NewOrder(false, 1))
+
+	type top struct{ bid, ask float64 }
+	var calls []top
+	ob.OnTopOfBookChange(func(bid, ask *Limit) {
+		calls = append(calls, top{topPrice(bid), topPrice(ask)})
+	})
+
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))   // new best bid
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))   // more size, same price
+	ob.PlaceLimitOrder(98, NewOrder(true, 1))   // behind the best
+	ob.PlaceLimitOrder(102, NewOrder(false, 1)) // behind the best
+	assert(t, calls, []top{{99, 101}})
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 1)) // new best ask
+	ob.PlaceMarketOrder(NewOrder(false, 1))     // partial at 99, level stays
+	assert(t, calls, []top{{99, 101}, {99, 100}})
+
+	ob.PlaceMarketOrder(NewOrder(false, 1)) // clears 99
+	ob.CancelOrder(ob.BestAsk().Orders[0])  // 100 goes, back to 101
+	assert(t, calls, []top{{99, 101}, {99, 100}, {98, 100}, {98, 101}})
+
+	ob.Reset()
+	assert(t, calls[len(calls)-1], top{0, 0})
+
+	ob.OnTopOfBookChange(nil)
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	assert(t, len(calls), 5)
+}
+
+func TestTicker(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetClock(func() time.Time { return time.Unix(0, 1_000) })
+	assert(t, ob.Ticker(), Ticker{Timestamp: 1_000})
+
+	ob.PlaceLimitOrder(99, NewOrder(true, 2))
+	ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	ob.PlaceLimitOrder(101, NewOrder(false, 4))
+	ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, ob.Ticker(), Ticker{
+		BidPrice:  99,
+		BidSize:   3,
+		AskPrice:  101,
+		AskSize:   3,
+		LastPrice: 101,
+		Timestamp: 1_000,
+	})
+}
+
+func TestTickerWhileTrading(t *testing.T) {
+	ob := NewOrderBook()
+	done := make(chan struct{})
+
+	// Quotes go up and come down again, always one bid at 99 and one ask at 101 together
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			bid, ask := NewOrder(true, 1), NewOrder(false, 2)
+			ob.Lock()
+			ob.PlaceLimitOrder(99, bid)
+			ob.PlaceLimitOrder(101, ask)
+			ob.Unlock()
+
+			ob.Lock()
+			ob.CancelOrder(bid)
+			ob.CancelOrder(ask)
+			ob.Unlock()
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		ticker := ob.Ticker()
+		if ticker.BidPrice == 0 {
+			assert(t, ticker, Ticker{Timestamp: ticker.Timestamp})
+		} else {
+			assert(t, ticker.BidSize, 1.0)
+			assert(t, ticker.AskPrice, 101.0)
+			assert(t, ticker.AskSize, 2.0)
+		}
+	}
+}
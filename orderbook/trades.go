@@ -0,0 +1,217 @@
+package orderbook
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrHistoryFull = errors.New("trade history is full, drain it before placing more orders")
+
+// What the book does once its trade history holds TradeHistorySize trades
+type HistoryPolicy int
+
+const (
+	DropOldest    HistoryPolicy = iota // forget the oldest trade for every new one, the default
+	ErrorWhenFull                      // keep every trade and reject new orders with ErrHistoryFull until DrainTrades
+)
+
+func (ob *Orderbook) recordTrade(m Match) {
+	ob.trades = append(ob.trades, m)
+	if ob.TradeHistoryPolicy == DropOldest && ob.TradeHistorySize > 0 && len(ob.trades) > ob.TradeHistorySize {
+		ob.trades = ob.trades[len(ob.trades)-ob.TradeHistorySize:]
+	}
+	ob.recordPrice(m.Timestamp, m.Price)
+	ob.countTrade(m)
+	ob.addUserVolume(m.Bid.TraderID, m)
+	if m.Ask.TraderID != m.Bid.TraderID {
+		ob.addUserVolume(m.Ask.TraderID, m)
+	}
+	ob.metrics().IncMatches()
+	ob.metrics().ObserveMatchSize(m.SizeFilled)
+	ob.checkCircuitBreaker(m.Price)
+	ob.publishTrade(m)
+	for _, cs := range ob.candleStreams {
+		cs.add(m)
+	}
+	ob.debug("order matched", "bid", m.Bid.ID, "ask", m.Ask.ID, "size", m.SizeFilled, "price", m.Price)
+}
+
+// Every match the book has produced, oldest first. With a TradeHistorySize
+// only the latest ones, and FlowImbalance, which is worked out from the tape,
+// only sees those. UserVolume and the fee tiers keep their own totals, so
+// capping or draining the tape doesn't touch them.
+func (ob *Orderbook) Trades() []Match {
+	return ob.trades
+}
+
+// How many trades the history holds right now
+func (ob *Orderbook) HistoryLen() int {
+	return len(ob.trades)
+}
+
+// Hands the trade history over and starts a new one, e.g. to write it to
+// durable storage once placing an order comes back with ErrHistoryFull
+func (ob *Orderbook) DrainTrades() []Match {
+	trades := ob.trades
+	ob.trades = nil
+	return trades
+}
+
+// With ErrorWhenFull, whether the history has no room left. An order that
+// trades can still take it past TradeHistorySize, the next one is refused.
+func (ob *Orderbook) tradeHistoryFull() bool {
+	return ob.TradeHistoryPolicy == ErrorWhenFull && ob.TradeHistorySize > 0 && len(ob.trades) >= ob.TradeHistorySize
+}
+
+// Price of the most recent trade, false if nothing has traded yet. Draining
+// the trade history doesn't forget it.
+func (ob *Orderbook) LastPrice() (float64, bool) {
+	if len(ob.priceSamples) == 0 {
+		return 0.0, false
+	}
+	return ob.priceSamples[len(ob.priceSamples)-1].price, true
+}
+
+// One of a trader's fills, with the trader's running total so UserVolume
+// doesn't have to walk the tape
+type traderFill struct {
+	timestamp int64
+	size      float64
+	total     float64 // the trader's volume up to and including this fill
+}
+
+// How far back a trader's fills are kept: the fee window, or
+// DefaultFeeWindow without a fee schedule
+func (ob *Orderbook) userVolumeWindow() time.Duration {
+	if ob.Fees == nil {
+		return DefaultFeeWindow
+	}
+	return ob.Fees.window()
+}
+
+// Called with each trade, once for every trader in it. Fills older than
+// userVolumeWindow go as the trader fills again.
+func (ob *Orderbook) addUserVolume(traderID string, m Match) {
+	if traderID == "" {
+		return
+	}
+
+	fills := ob.userFills[traderID]
+	cutoff := m.Timestamp - int64(ob.userVolumeWindow())
+	fills = fills[sort.Search(len(fills), func(i int) bool { return fills[i].timestamp >= cutoff }):]
+
+	total := m.SizeFilled
+	if len(fills) > 0 {
+		total += fills[len(fills)-1].total
+	}
+	if ob.userFills == nil {
+		ob.userFills = make(map[string][]traderFill)
+	}
+	ob.userFills[traderID] = append(fills, traderFill{timestamp: m.Timestamp, size: m.SizeFilled, total: total})
+}
+
+// Total size a trader has bought or sold (as maker or taker) within the
+// window. Fills are only kept for the fee window (DefaultFeeWindow without
+// fees), a longer window doesn't see further back.
+func (ob *Orderbook) UserVolume(traderID string, window time.Duration) float64 {
+	if traderID == "" {
+		return 0.0 // anonymous orders don't build up volume
+	}
+
+	since := ob.now().Add(-window).UnixNano()
+	fills := ob.userFills[traderID]
+
+	// Fills are in time order, find the first one in the window
+	i := sort.Search(len(fills), func(i int) bool { return fills[i].timestamp >= since })
+	if i == len(fills) {
+		return 0.0
+	}
+	return fills[len(fills)-1].total - (fills[i].total - fills[i].size)
+}
+
+// Which way the trades within the window leaned, from -1 (all sell-initiated
+// volume) to +1 (all buy-initiated). The taker side decides who initiated a
+// trade. 0 when nothing traded.
+func (ob *Orderbook) FlowImbalance(window time.Duration) float64 {
+	since := ob.now().Add(-window).UnixNano()
+	var bought, sold float64
+
+	for i := len(ob.trades) - 1; i >= 0; i-- {
+		trade := ob.trades[i]
+		if trade.Timestamp < since {
+			break
+		}
+
+		if trade.BuyInitiated {
+			bought += trade.SizeFilled
+		} else {
+			sold += trade.SizeFilled
+		}
+	}
+
+	if bought+sold == 0 {
+		return 0
+	}
+	return (bought - sold) / (bought + sold)
+}
+
+// How a trader did on round trips in these matches: their volume weighted
+// average buy and sell price, and what they made per unit, avgSell - avgBuy.
+// A side the trader never traded on comes back as 0, and so does netPerUnit.
+func SpreadCapture(trades []Match, traderID string) (avgBuy, avgSell, netPerUnit float64) {
+	var boughtSize, boughtNotional, soldSize, soldNotional float64
+
+	for _, trade := range trades {
+		if trade.Bid.TraderID == traderID {
+			boughtSize += trade.SizeFilled
+			boughtNotional += trade.SizeFilled * trade.Price
+		}
+		if trade.Ask.TraderID == traderID {
+			soldSize += trade.SizeFilled
+			soldNotional += trade.SizeFilled * trade.Price
+		}
+	}
+
+	if boughtSize > 0 {
+		avgBuy = boughtNotional / boughtSize
+	}
+	if soldSize > 0 {
+		avgSell = soldNotional / soldSize
+	}
+	if boughtSize > 0 && soldSize > 0 {
+		netPerUnit = avgSell - avgBuy
+	}
+
+	return avgBuy, avgSell, netPerUnit
+}
+
+// Writes the tape as CSV, one row per match with a 1-based sequence number.
+// An empty tape still gets the header.
+func (ob *Orderbook) WriteTradesCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"seq", "timestamp", "price", "size", "bid_id", "ask_id"}); err != nil {
+		return err
+	}
+
+	for i, trade := range ob.trades {
+		row := []string{
+			strconv.Itoa(i + 1),
+			strconv.FormatInt(trade.Timestamp, 10),
+			strconv.FormatFloat(trade.Price, 'f', -1, 64),
+			strconv.FormatFloat(trade.SizeFilled, 'f', -1, 64),
+			strconv.FormatInt(trade.Bid.ID, 10),
+			strconv.FormatInt(trade.Ask.ID, 10),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,135 @@
+package orderbook
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWriteTradesCSV(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetClock(func() time.Time { return time.Unix(0, 1_000) })
+
+	sellA := NewOrder(false, 2)
+	sellB := NewOrder(false, 3)
+	buy := NewOrder(true, 4.5)
+	ob.PlaceLimitOrder(100, sellA)
+	ob.PlaceLimitOrder(100.5, sellB)
+	ob.PlaceMarketOrder(buy)
+
+	var buf bytes.Buffer
+	assert(t, ob.WriteTradesCSV(&buf), nil)
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert(t, err, nil)
+	assert(t, len(rows), 3)
+	assert(t, rows[0], []string{"seq", "timestamp", "price", "size", "bid_id", "ask_id"})
+	assert(t, rows[1], []string{"1", "1000", "100", "2", strconv.FormatInt(buy.ID, 10), strconv.FormatInt(sellA.ID, 10)})
+	assert(t, rows[2], []string{"2", "1000", "100.5", "2.5", strconv.FormatInt(buy.ID, 10), strconv.FormatInt(sellB.ID, 10)})
+}
+
+func TestWriteTradesCSVEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	assert(t, NewOrderBook().WriteTradesCSV(&buf), nil)
+	assert(t, buf.String(), "seq,timestamp,price,size,bid_id,ask_id\n")
+}
+
+func TestSpreadCapture(t *testing.T) {
+	ob := NewOrderBook()
+
+	// the market maker quotes 99 / 101 twice and gets hit on both sides
+	for i := 0; i < 2; i++ {
+		ob.PlaceLimitOrder(99, NewOrder(true, 5, WithTraderID("mm")))
+		ob.PlaceLimitOrder(101, NewOrder(false, 5, WithTraderID("mm")))
+		ob.PlaceMarketOrder(NewOrder(false, 5, WithTraderID("alice")))
+		ob.PlaceMarketOrder(NewOrder(true, 5, WithTraderID("bob")))
+	}
+
+	avgBuy, avgSell, net := SpreadCapture(ob.Trades(), "mm")
+	assert(t, avgBuy, 99.0)
+	assert(t, avgSell, 101.0)
+	assert(t, net, 2.0)
+
+	// alice only sold
+	avgBuy, avgSell, net = SpreadCapture(ob.Trades(), "alice")
+	assert(t, avgBuy, 0.0)
+	assert(t, avgSell, 99.0)
+	assert(t, net, 0.0)
+}
+
+func TestFlowImbalance(t *testing.T) {
+	ob := NewOrderBook()
+	now := time.Unix(0, 0)
+	ob.SetClock(func() time.Time { return now })
+	assert(t, ob.FlowImbalance(time.Minute), 0.0)
+
+	// An old sell-initiated trade, then 3 bought and 1 sold within the minute
+	ob.PlaceLimitOrder(99, NewOrder(true, 10))
+	ob.PlaceMarketOrder(NewOrder(false, 10))
+
+	now = now.Add(time.Hour)
+	ob.PlaceLimitOrder(101, NewOrder(false, 3))
+	matches, _ := ob.PlaceLimitOrder(101, NewOrder(true, 3))
+	assert(t, matches[0].BuyInitiated, true)
+
+	ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	matches, _ = ob.PlaceMarketOrder(NewOrder(false, 1))
+	assert(t, matches[0].BuyInitiated, false)
+
+	assert(t, ob.FlowImbalance(time.Minute), 0.5)     // (3 - 1) / 4
+	assert(t, ob.FlowImbalance(2*time.Hour), -8.0/14) // (3 - 11) / 14
+
+	now = now.Add(time.Hour)
+	assert(t, ob.FlowImbalance(time.Minute), 0.0)
+}
+
+func TestTradeHistoryDropOldest(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TradeHistorySize = 2
+	ob.PlaceLimitOrder(100, NewOrder(false, 3))
+
+	for i := 0; i < 2; i++ {
+		ob.PlaceMarketOrder(NewOrder(true, 1))
+	}
+	assert(t, ob.HistoryLen(), 2)
+
+	// One past the cap, the first trade goes
+	last := NewOrder(true, 1)
+	_, err := ob.PlaceMarketOrder(last)
+	assert(t, err, nil)
+	assert(t, ob.HistoryLen(), 2)
+	assert(t, ob.Trades()[1].Bid, last)
+}
+
+func TestTradeHistoryErrorWhenFull(t *testing.T) {
+	ob := NewOrderBook()
+	ob.TradeHistorySize = 2
+	ob.TradeHistoryPolicy = ErrorWhenFull
+	ob.PlaceLimitOrder(100, NewOrder(false, 5))
+
+	_, err := ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, err, nil)
+	_, err = ob.PlaceLimitOrder(100, NewOrder(true, 1))
+	assert(t, err, nil)
+	assert(t, ob.HistoryLen(), 2)
+
+	// At the cap nothing is dropped, new orders are refused instead
+	_, err = ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, err, ErrHistoryFull)
+	_, err = ob.PlaceLimitOrder(99, NewOrder(true, 1))
+	assert(t, err, ErrHistoryFull)
+	assert(t, ob.AskTotalVolume(), 3.0)
+
+	trades := ob.DrainTrades()
+	assert(t, len(trades), 2)
+	assert(t, ob.HistoryLen(), 0)
+	lastPrice, ok := ob.LastPrice()
+	assert(t, lastPrice, 100.0)
+	assert(t, ok, true)
+
+	_, err = ob.PlaceMarketOrder(NewOrder(true, 1))
+	assert(t, err, nil)
+	assert(t, ob.HistoryLen(), 1)
+}
@@ -0,0 +1,69 @@
+package orderbook
+
+import "time"
+
+type priceSample struct {
+	timestamp int64 // book clock, unix nanos
+	price     float64
+}
+
+// Remembers the new last price if it moved, dropping samples that have aged
+// out of PriceSampleRetention. The one in force at the cutoff stays, it's still
+// the price at the start of the retained span.
+func (ob *Orderbook) recordPrice(timestamp int64, price float64) {
+	if n := len(ob.priceSamples); n > 0 && ob.priceSamples[n-1].price == price {
+		return
+	}
+	ob.priceSamples = append(ob.priceSamples, priceSample{timestamp: timestamp, price: price})
+
+	if ob.PriceSampleRetention <= 0 {
+		return
+	}
+	cutoff := timestamp - int64(ob.PriceSampleRetention)
+	i := 0
+	for i+1 < len(ob.priceSamples) && ob.priceSamples[i+1].timestamp <= cutoff {
+		i++
+	}
+	ob.priceSamples = ob.priceSamples[i:]
+}
+
+// Time-weighted average of the last price over the window ending now. Each
+// price counts for as long as it stood within the window, the one in force
+// when the window starts from the start on. False if nothing has traded yet.
+func (ob *Orderbook) TWAP(window time.Duration) (float64, bool) {
+	now := ob.now().UnixNano()
+	since := now - int64(window)
+
+	// Samples are in time order, find the last one at or before the window
+	// start, or the first one if they all come after it
+	first := len(ob.priceSamples)
+	for first > 0 && ob.priceSamples[first-1].timestamp > since {
+		first--
+	}
+	if first > 0 {
+		first--
+	}
+	samples := ob.priceSamples[first:]
+	if len(samples) == 0 {
+		return 0.0, false
+	}
+
+	var weighted, total float64
+	for i, s := range samples {
+		start := s.timestamp
+		if start < since {
+			start = since
+		}
+		end := now
+		if i+1 < len(samples) {
+			end = samples[i+1].timestamp
+		}
+		weighted += s.price * float64(end-start)
+		total += float64(end - start)
+	}
+
+	if total == 0 {
+		return samples[len(samples)-1].price, true // only just changed
+	}
+	return weighted / total, true
+}
@@ -0,0 +1,71 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTWAP(t *testing.T) {
+	ob := NewOrderBook()
+	now := time.Unix(1_700_000_000, 0)
+	ob.SetClock(func() time.Time { return now })
+
+	_, ok := ob.TWAP(time.Minute)
+	assert(t, ok, false)
+
+	trade := func(price float64) {
+		ob.PlaceLimitOrder(price, NewOrder(false, 1))
+		ob.PlaceMarketOrder(NewOrder(true, 1))
+	}
+
+	trade(100)
+	now = now.Add(10 * time.Second)
+	trade(100) // same price, not a new sample
+	now = now.Add(20 * time.Second)
+	trade(110)
+	now = now.Add(10 * time.Second)
+
+	// 100 for 30s, 110 for 10s
+	twap, ok := ob.TWAP(time.Minute)
+	assert(t, ok, true)
+	assert(t, twap, 102.5)
+
+	// 100 was still in force when the last 15s started: 100 for 5s, 110 for 10s
+	twap, _ = ob.TWAP(15 * time.Second)
+	assert(t, twap, (100*5+110*10)/15.0)
+
+	// Nothing traded for a minute, 110 stood the whole window
+	now = now.Add(time.Minute)
+	twap, ok = ob.TWAP(time.Minute)
+	assert(t, ok, true)
+	assert(t, twap, 110.0)
+}
+
+func TestTWAPJustChanged(t *testing.T) {
+	ob := NewOrderBook()
+	ob.SetClock(func() time.Time { return time.Unix(0, 0) })
+
+	ob.PlaceLimitOrder(100, NewOrder(false, 1))
+	ob.PlaceMarketOrder(NewOrder(true, 1))
+
+	twap, ok := ob.TWAP(time.Second)
+	assert(t, ok, true)
+	assert(t, twap, 100.0)
+}
+
+func TestPriceSampleRetention(t *testing.T) {
+	ob := NewOrderBook()
+	ob.PriceSampleRetention = time.Minute
+	now := time.Unix(1_700_000_000, 0)
+	ob.SetClock(func() time.Time { return now })
+
+	for i := 0; i < 5; i++ {
+		ob.PlaceLimitOrder(float64(100+i), NewOrder(false, 1))
+		ob.PlaceMarketOrder(NewOrder(true, 1))
+		now = now.Add(30 * time.Second)
+	}
+	assert(t, len(ob.priceSamples), 3)
+
+	ob.Reset()
+	assert(t, len(ob.priceSamples), 0)
+}
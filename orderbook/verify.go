@@ -0,0 +1,104 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInconsistent = errors.New("order book is inconsistent")
+
+// Volumes are sums of float sizes, they only have to agree this closely
+const verifyEpsilon = 1e-9
+
+// Checks the book's invariants: every order in Orders sits on the level it
+// points to, each level's TotalVolume adds up, the limit maps and the sorted
+// sides hold the same levels, and the book isn't crossed (outside an
+// auction). Returns an error wrapping ErrInconsistent for the first thing
+// that's off, meant for tests and for sanity checks after a restore.
+func (ob *Orderbook) Verify() error {
+	for id, o := range ob.Orders {
+		if o.ID != id {
+			return fmt.Errorf("%w: order %d is filed under id %d", ErrInconsistent, o.ID, id)
+		}
+		if o.Limit == nil {
+			return fmt.Errorf("%w: order %d isn't on a level", ErrInconsistent, id)
+		}
+		if !o.Limit.contains(o) {
+			return fmt.Errorf("%w: level %.2f doesn't hold order %d", ErrInconsistent, o.Limit.Price, id)
+		}
+	}
+
+	for _, bid := range []bool{false, true} {
+		if err := ob.verifySide(bid); err != nil {
+			return err
+		}
+	}
+
+	bestBid, bestAsk := ob.BestBid(), ob.BestAsk()
+	if bestBid != nil && bestAsk != nil {
+		crossed := bestBid.Price > bestAsk.Price || (bestBid.Price == bestAsk.Price && !ob.RestAtEqualPrice)
+		if crossed && !ob.auction {
+			return fmt.Errorf("%w: best bid %.2f crosses best ask %.2f", ErrInconsistent, bestBid.Price, bestAsk.Price)
+		}
+	}
+
+	return nil
+}
+
+func (ob *Orderbook) verifySide(bid bool) error {
+	name, limits, total := "ask", ob.AskLimits, ob.askVolume
+	if bid {
+		name, limits, total = "bid", ob.BidLimits, ob.bidVolume
+	}
+
+	side := ob.limits(bid)
+	if len(side) != len(limits) {
+		return fmt.Errorf("%w: %d %s levels but %d in the %s map", ErrInconsistent, len(side), name, len(limits), name)
+	}
+
+	sum := 0.0
+	for i, l := range side {
+		if limits[l.Price] != l {
+			return fmt.Errorf("%w: %s level %.2f is missing from the map", ErrInconsistent, name, l.Price)
+		}
+		if i > 0 && !better(bid, side[i-1].Price, l.Price) {
+			return fmt.Errorf("%w: %s level %.2f is out of order", ErrInconsistent, name, l.Price)
+		}
+		if ob.levels(bid).Get(l.Price) != l {
+			return fmt.Errorf("%w: %s level %.2f can't be looked up by its price", ErrInconsistent, name, l.Price)
+		}
+		if len(l.Orders) == 0 {
+			return fmt.Errorf("%w: %s level %.2f is empty", ErrInconsistent, name, l.Price)
+		}
+
+		volume := 0.0
+		for _, o := range l.Orders {
+			if o.Limit != l || ob.Orders[o.ID] != o {
+				return fmt.Errorf("%w: order %d on %s level %.2f isn't tracked", ErrInconsistent, o.ID, name, l.Price)
+			}
+			if o.Bid != bid {
+				return fmt.Errorf("%w: order %d is on the wrong side", ErrInconsistent, o.ID)
+			}
+			volume += o.Size
+		}
+		if math.Abs(volume-l.TotalVolume) > verifyEpsilon {
+			return fmt.Errorf("%w: %s level %.2f has volume %.2f but its orders add up to %.2f", ErrInconsistent, name, l.Price, l.TotalVolume, volume)
+		}
+		sum += l.TotalVolume
+	}
+
+	if math.Abs(sum-total) > verifyEpsilon {
+		return fmt.Errorf("%w: %s volume is %.2f but the levels add up to %.2f", ErrInconsistent, name, total, sum)
+	}
+	return nil
+}
+
+func (l *Limit) contains(o *Order) bool {
+	for _, other := range l.Orders {
+		if other == o {
+			return true
+		}
+	}
+	return false
+}
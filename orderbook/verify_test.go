@@ -0,0 +1,63 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+)
+
+func verifiedBook() (*Orderbook, *Order) {
+	ob := NewOrderBook()
+	resting := NewOrder(false, 5)
+	ob.PlaceLimitOrder(101, resting)
+	ob.PlaceLimitOrder(102, NewOrder(false, 3, WithDisplaySize(1)))
+	ob.PlaceLimitOrder(99, NewOrder(true, 4))
+	ob.PlaceLimitOrder(98, NewOrder(true, 2))
+	ob.PlaceMarketOrder(NewOrder(true, 2))
+	return ob, resting
+}
+
+func TestVerify(t *testing.T) {
+	ob, _ := verifiedBook()
+	assert(t, ob.Verify(), nil)
+	assert(t, NewOrderBook().Verify(), nil)
+}
+
+func TestVerifyCatchesCorruption(t *testing.T) {
+	for name, corrupt := range map[string]func(*Orderbook, *Order){
+		"volume off": func(ob *Orderbook, o *Order) {
+			o.Limit.TotalVolume++
+		},
+		"side total off": func(ob *Orderbook, o *Order) {
+			ob.askVolume++
+		},
+		"order off its level": func(ob *Orderbook, o *Order) {
+			o.Limit = nil
+		},
+		"order missing from level": func(ob *Orderbook, o *Order) {
+			o.Limit.Orders = o.Limit.Orders[1:]
+		},
+		"level missing from map": func(ob *Orderbook, o *Order) {
+			delete(ob.AskLimits, 101)
+		},
+		"levels out of order": func(ob *Orderbook, o *Order) {
+			l := ob.AskLimits[101]
+			delete(ob.AskLimits, 101)
+			l.Price = 103
+			ob.AskLimits[103] = l
+		},
+		"crossed": func(ob *Orderbook, o *Order) {
+			l := ob.BidLimits[99]
+			delete(ob.BidLimits, 99)
+			l.Price = 101.5
+			ob.BidLimits[101.5] = l
+		},
+	} {
+		ob, resting := verifiedBook()
+		corrupt(ob, resting)
+
+		err := ob.Verify()
+		if !errors.Is(err, ErrInconsistent) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+}